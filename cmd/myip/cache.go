@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/cache"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+const (
+	// Duration before a pending background refresh of the cache is ignored.
+	refreshLock = time.Minute
+	lockPerm    = 0o644
+)
+
+// Caching reports whether the results are stored in the cache.
+func (m modes) caching() bool {
+	return m.maxAge > 0 || m.refresh
+}
+
+// Cached replays the cached results when they are younger than the max age.
+// In the background mode, stale results are also replayed while a separate
+// myip process refreshes the cache.
+func (m *modes) cached() {
+	if m.maxAge <= 0 || m.refresh {
+		return
+	}
+	name, err := cache.File()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %s\n", err)
+		return
+	}
+	c, err := cache.Load(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %s\n", err)
+		return
+	}
+	f, names := m.family(), m.registry.Names()
+	if c.Fresh(f, names, m.maxAge) {
+		m.registry, m.hit = c.Registry(f, names), true
+		return
+	}
+	if _, ok := c.Get(f, names); !ok || !m.background {
+		return
+	}
+	m.registry, m.hit = c.Registry(f, names), true
+	if err := m.revalidate(name); err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %s\n", err)
+	}
+}
+
+// Store writes the results to the cache, unless they were replayed from it.
+// The results are keyed by the address family and the enabled providers.
+func (m modes) store(results []provider.Result) {
+	if m.hit || !m.caching() {
+		return
+	}
+	name, err := cache.File()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %s\n", err)
+		return
+	}
+	c, err := cache.Load(name)
+	if err != nil {
+		c = cache.Cache{}
+	}
+	c.Put(m.registry.Names(), results)
+	if err := c.Save(name); err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %s\n", err)
+	}
+	_ = os.Remove(name + ".refresh")
+}
+
+// Revalidate starts a myip process that refreshes the cache in the background.
+// The process is given the same arguments as this one, so it uses the same
+// providers and on-change actions, and it honors the history option.
+// A lock file prevents multiple refreshes running at the same time.
+func (m modes) revalidate(name string) error {
+	lock := name + ".refresh"
+	if st, err := os.Stat(lock); err == nil && time.Since(st.ModTime()) < refreshLock {
+		return nil
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.WriteFile(lock, nil, lockPerm); err != nil {
+		return err
+	}
+	exe, err := self()
+	if err != nil {
+		return err
+	}
+	args := append([]string{"-refresh"}, os.Args[1:]...)
+	cmd := exec.Command(exe, args...)
+	if err := cmd.Start(); err != nil {
+		_ = os.Remove(lock)
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bengarrett/myip/pkg/config"
+	"github.com/bengarrett/myip/pkg/custom"
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+var (
+	errFormat   = errors.New("format is unknown, it must be either text, json, ndjson, csv or tsv")
+	errProvider = errors.New("provider is unknown")
+)
+
+// Settings returns the configuration file and environment variable settings.
+// Any invalid setting is printed and exits the program.
+func settings() config.Config {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return cfg
+}
+
+// LoadConfig loads the configuration file and environment variable settings
+// and applies the geolocation language.
+func loadConfig() (config.Config, error) {
+	name, err := config.Path()
+	if err != nil {
+		return config.Config{}, err
+	}
+	cfg, _, err := config.Load(name)
+	if err != nil {
+		return cfg, err
+	}
+	if !formats(cfg.Format) {
+		return cfg, fmt.Errorf("config format: %q: %w", cfg.Format, errFormat)
+	}
+	if err := geolite2.SetLanguage(cfg.Language); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Defaults applies the configured mode, unless a mode flag was given.
+// The ipv6 flag selects the address family, so it also stops
+// a configured both mode from overriding it.
+func (m *modes) defaults(cfg config.Config, fs *flag.FlagSet) {
+	given, family := false, false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "both", "b", "consensus", "c", "first", "f":
+			given = true
+		case "ipv6", "i":
+			family = true
+		}
+	})
+	if given {
+		return
+	}
+	switch cfg.Mode {
+	case config.Both:
+		m.both = !family
+	case config.Consensus:
+		m.consensus = true
+	case config.First:
+		m.first = true
+	}
+}
+
+// ProvidersFlag defines the providers flag of the set,
+// a comma separated list of names that replaces the configured providers.
+func providersFlag(fs *flag.FlagSet, cfg *config.Config) {
+	fs.Func("providers", "comma separated names of the providers to request, from "+
+		strings.Join(available(), ", ")+" and the providers-file",
+		func(s string) error {
+			cfg.Providers = config.List(s)
+			return nil
+		})
+}
+
+// Available returns the names of the built-in and optional providers.
+func available() []string {
+	return provider.NewRegistry(provider.Available(nil)...).Names()
+}
+
+// Enabled returns a registry of the named providers using the HTTP client.
+// The names can include the optional providers and the custom providers
+// defined in the named providers file. When no names are given,
+// it returns the built-in and the custom providers.
+func enabled(c *http.Client, names []string, file string) (*provider.Registry, error) {
+	defs := []custom.Definition{}
+	if file != "" {
+		var err error
+		if defs, err = custom.Load(file); err != nil {
+			return nil, err
+		}
+	}
+	all := provider.NewRegistry(provider.Available(c)...)
+	for _, p := range custom.Providers(c, defs...) {
+		if err := all.Register(p); err != nil {
+			return nil, fmt.Errorf("providers file %s: %w", file, err)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{}
+		for _, p := range provider.Builtin(nil) {
+			names = append(names, p.Name())
+		}
+		for _, d := range defs {
+			names = append(names, d.Name)
+		}
+	}
+	r := provider.NewRegistry()
+	for _, name := range names {
+		p, ok := all.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("%q: %w, use one of %s", name, errProvider, strings.Join(all.Names(), ", "))
+		}
+		if err := r.Register(p); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Configure runs the config command using the arguments and returns the exit code.
+func configure(args []string) int {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = usage(fs, "myip config show")
+	_ = fs.Parse(args)
+	if fs.Arg(0) != "show" {
+		fs.Usage()
+		return 2
+	}
+	return show()
+}
+
+// Show prints the path of the configuration file,
+// followed by each setting with its value and source.
+func show() int {
+	name, err := config.Path()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	found := "not found"
+	if _, err := os.Stat(name); err == nil {
+		found = "found"
+	}
+	fmt.Printf("config file: %s (%s)\n\n", name, found)
+	cfg, src, err := config.Load(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(w, "setting\tvalue\tsource")
+	for _, v := range cfg.Values() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v[0], v[1], src[v[0]])
+	}
+	w.Flush()
+	fmt.Println("\nprecedence: flags > environment > config file > default")
+	fmt.Printf("available providers: %s\n", strings.Join(available(), ", "))
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/config"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+func TestModes_defaults(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		args []string
+		want provider.Family
+		cons bool
+	}{
+		{"config both", config.Both, nil, provider.Both, false},
+		{"ipv6 flag", config.Both, []string{"-ipv6"}, provider.IPv6, false},
+		{"ipv6 alias", config.Both, []string{"-i"}, provider.IPv6, false},
+		{"both flag", config.Consensus, []string{"-b"}, provider.Both, false},
+		{"config consensus", config.Consensus, nil, provider.IPv4, true},
+		{"config consensus ipv6", config.Consensus, []string{"-ipv6"}, provider.IPv6, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m modes
+			fs := flag.NewFlagSet("myip", flag.ContinueOnError)
+			fs.BoolVar(&m.both, "both", false, "")
+			fs.BoolVar(&m.both, "b", false, "alias for both")
+			fs.BoolVar(&m.ipv6, "ipv6", false, "")
+			fs.BoolVar(&m.ipv6, "i", false, "alias for ipv6")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			cfg := config.Defaults()
+			cfg.Mode = tt.mode
+			m.defaults(cfg, fs)
+			if got := m.family(); got != tt.want {
+				t.Errorf("defaults() family = %v, want %v", got, tt.want)
+			}
+			if m.consensus != tt.cons {
+				t.Errorf("defaults() consensus = %v, want %v", m.consensus, tt.cons)
+			}
+		})
+	}
+}
+
+func TestProvidersFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"config", nil, []string{"ipify", "seeip"}, false},
+		{"flag", []string{"-providers=stun, opendns"}, []string{"stun", "opendns"}, false},
+		{"unknown", []string{"-providers=ipify,nope"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Defaults()
+			cfg.Providers = []string{"ipify", "seeip"}
+			fs := flag.NewFlagSet("myip", flag.ContinueOnError)
+			providersFlag(fs, &cfg)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			r, err := enabled(nil, cfg.Providers, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("enabled() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := r.Names(); !slices.Equal(got, tt.want) {
+				t.Errorf("enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
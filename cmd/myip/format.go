@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+)
+
+// Output formats.
+const (
+	text   = "text"
+	jsonf  = "json"
+	ndjson = "ndjson"
+	csvf   = "csv"
+	tsv    = "tsv"
+)
+
+// Formats reports whether s is a supported output format.
+func formats(s string) bool {
+	switch s {
+	case text, jsonf, ndjson, csvf, tsv:
+		return true
+	}
+	return false
+}
+
+// Results returns every provider result or, in the first mode,
+// only the quickest successful reply. When no provider replies with
+// an IP address, the first mode returns the results of every failure.
+func (m modes) results(ctx context.Context, r resolver.Resolver) []provider.Result {
+	if !m.first {
+		return m.all(ctx, r)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	failed := []provider.Result{}
+	for res := range r.Stream(ctx) {
+		if res.OK() {
+			m.see(res)
+			return []provider.Result{res}
+		}
+		failed = append(failed, res)
+	}
+	return failed
+}
+
+// All returns every provider result and keeps them for the on-change actions.
+func (m modes) all(ctx context.Context, r resolver.Resolver) []provider.Result {
+	results := r.Results(ctx)
+	m.see(results...)
+	return results
+}
+
+// One returns the quickest successful reply and keeps it for the on-change actions.
+func (m modes) one(ctx context.Context, r resolver.Resolver) (provider.Result, error) {
+	res, err := r.First(ctx)
+	if err == nil {
+		m.see(res)
+	}
+	return res, err
+}
+
+// See keeps the results for the on-change actions.
+func (m modes) see(results ...provider.Result) {
+	if m.seen != nil {
+		*m.seen = append(*m.seen, results...)
+	}
+}
+
+// Record is a provider result for the structured output formats.
+type record struct {
+	Provider string `json:"provider"`
+	Family   string `json:"family"`
+	IP       string `json:"ip"`
+	City     string `json:"city"`
+	Country  string `json:"country"`
+	ISOCode  string `json:"country_code"`
+	Latency  int64  `json:"latency_ms"`
+	Error    string `json:"error,omitempty"`
+
+	Attributes provider.Attributes `json:"attributes,omitempty"`
+}
+
+func newRecord(r provider.Result) record {
+	rec := record{
+		Provider: r.Provider,
+		Family:   r.Family.String(),
+		IP:       r.IP,
+		City:     r.Location.City,
+		Country:  r.Location.Country,
+		ISOCode:  r.Location.ISOCode,
+		Latency:  r.Latency.Milliseconds(),
+
+		Attributes: r.Attributes,
+	}
+	if r.Err != nil {
+		rec.Error = r.Err.Error()
+	}
+	return rec
+}
+
+// Header returns the column names of a record for the tabular output formats.
+func header() []string {
+	return []string{"provider", "family", "ip", "city", "country", "latency_ms", "error"}
+}
+
+// Row returns the record values in the order of the header columns.
+func (rec record) row() []string {
+	return []string{
+		rec.Provider,
+		rec.Family,
+		rec.IP,
+		rec.City,
+		rec.Country,
+		strconv.FormatInt(rec.Latency, 10),
+		rec.Error,
+	}
+}
+
+// Summary is the consensus of the results of an address family.
+type summary struct {
+	Family string `json:"family"`
+	IP     string `json:"ip"`
+	Agree  int    `json:"agree"`
+	Total  int    `json:"total"`
+	Failed int    `json:"failed"`
+	Quorum bool   `json:"quorum"`
+}
+
+func newSummary(f provider.Family, results []provider.Result) summary {
+	c := resolver.Vote(resolver.Filter(results, f))
+	return summary{
+		Family: f.String(),
+		IP:     c.IP,
+		Agree:  len(c.Agree),
+		Total:  c.Total(),
+		Failed: len(c.Failed),
+		Quorum: c.Quorum(),
+	}
+}
+
+// Document is the complete JSON output of a run.
+type document struct {
+	Time      time.Time `json:"time"`
+	Summaries []summary `json:"summary"`
+	Results   []record  `json:"results"`
+}
+
+func newDocument(f provider.Family, results []provider.Result) document {
+	doc := document{
+		Time:      time.Now(),
+		Summaries: []summary{},
+		Results:   []record{},
+	}
+	for _, x := range f.Families() {
+		doc.Summaries = append(doc.Summaries, newSummary(x, results))
+	}
+	for _, r := range results {
+		doc.Results = append(doc.Results, newRecord(r))
+	}
+	return doc
+}
+
+// JSON prints the results as an indented JSON document.
+// The first mode prints only the quickest successful reply.
+// It returns false when no provider returns an IP address, or
+// when the consensus mode fails to reach a quorum.
+func (m modes) json(ctx context.Context, r resolver.Resolver) bool {
+	results := m.results(ctx, r)
+	doc := newDocument(r.Family, results)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return ok(m.consensus, doc.Summaries)
+}
+
+// Final is the closing object of the NDJSON output.
+type final struct {
+	Time      time.Time `json:"time"`
+	Summaries []summary `json:"summary"`
+}
+
+// NDJSON prints each result as a JSON object on a new line as the replies come in,
+// followed by an object that summarizes the results of each address family.
+// The first mode stops after the quickest successful reply.
+// It returns false when no provider returns an IP address, or
+// when the consensus mode fails to reach a quorum.
+func (m modes) ndjson(ctx context.Context, r resolver.Resolver) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	enc := json.NewEncoder(os.Stdout)
+	results := []provider.Result{}
+	for res := range r.Stream(ctx) {
+		m.see(res)
+		results = append(results, res)
+		if err := enc.Encode(newRecord(res)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		if m.first && res.OK() {
+			break
+		}
+	}
+	doc := newDocument(r.Family, results)
+	if err := enc.Encode(final{Time: doc.Time, Summaries: doc.Summaries}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return ok(m.consensus, doc.Summaries)
+}
+
+// Table prints the results as comma-separated or, when tab is true,
+// tab-separated values with a header row.
+// The first mode prints only the quickest successful reply.
+// It returns false when no provider returns an IP address, or
+// when the consensus mode fails to reach a quorum.
+func (m modes) table(ctx context.Context, r resolver.Resolver, tab bool) bool {
+	results := m.results(ctx, r)
+	w := csv.NewWriter(os.Stdout)
+	if tab {
+		w.Comma = '\t'
+	}
+	_ = w.Write(header())
+	for _, res := range results {
+		_ = w.Write(newRecord(res).row())
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	doc := newDocument(r.Family, results)
+	return ok(m.consensus, doc.Summaries)
+}
+
+// Ok returns true when any summary has an IP address or,
+// when consensus is true, every summary reaches a quorum.
+func ok(consensus bool, sums []summary) bool {
+	found := false
+	for _, s := range sums {
+		if consensus && !s.Quorum {
+			return false
+		}
+		if s.IP != "" {
+			found = true
+		}
+	}
+	return found
+}
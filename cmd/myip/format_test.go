@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+)
+
+func TestModes_results(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		first    bool
+		want     int
+		wantErr  bool
+	}{
+		{"all", myiptest.OK, false, 4, false},
+		{"first", myiptest.OK, true, 1, false},
+		{"all failed", myiptest.Status, false, 4, true},
+		{"first failed", myiptest.Status, true, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := resolver.New(provider.IPv4, 5*time.Second)
+			r.Registry = myiptest.Registry(t, tt.behavior)
+			m := modes{first: tt.first}
+			results := m.results(context.Background(), r)
+			if len(results) != tt.want {
+				t.Fatalf("results() = %d results, want %d", len(results), tt.want)
+			}
+			for _, res := range results {
+				if (res.Err != nil) != tt.wantErr {
+					t.Errorf("results() %s error = %v, wantErr %v", res.Provider, res.Err, tt.wantErr)
+				}
+			}
+			doc := newDocument(r.Family, results)
+			if len(doc.Results) != tt.want {
+				t.Errorf("newDocument() = %d results, want %d", len(doc.Results), tt.want)
+			}
+		})
+	}
+}
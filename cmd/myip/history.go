@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/history"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+var errDate = errors.New("date must use the format YYYY-MM-DD or YYYY-MM-DD HH:MM")
+
+// Recall runs the history command using the arguments and returns the exit code.
+// It lists the IP addresses stored in the history file or summarises how long each was held.
+func recall(args []string) int {
+	var from, to time.Time
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	fs.Func("from", "only include the addresses observed on or after the date, for example 2022-01-31",
+		func(s string) (err error) {
+			from, err = parseDate(s, false)
+			return err
+		})
+	summary := fs.Bool("summary", false, "summarise how long each IP address and location was held")
+	fs.Func("to", "only include the addresses observed on or before the date, for example 2022-12-31",
+		func(s string) (err error) {
+			to, err = parseDate(s, true)
+			return err
+		})
+	fs.Usage = usage(fs, "myip history [options]")
+	_ = fs.Parse(args)
+
+	name, err := history.File()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	records, err := history.Read(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if !errors.Is(err, history.ErrRecord) {
+			return 1
+		}
+		// corrupt lines are skipped, so continue with the valid records
+	}
+	records = history.Filter(records, from, to)
+	if len(records) == 0 {
+		fmt.Fprintf(os.Stderr, "no ip addresses found in the history file %s\n", name)
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+	defer w.Flush()
+	if !*summary {
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Time.Local().Format(time.DateTime), r.Family, r.IP, r.Location())
+		}
+		return 0
+	}
+	for _, s := range history.Summarise(records) {
+		held := held(s.Duration())
+		if s.Current {
+			held += " (current)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s to %s\t%s\n", s.Family, s.IP, s.Location(),
+			s.Time.Local().Format(time.DateTime), s.Until.Local().Format(time.DateTime), held)
+	}
+	return 0
+}
+
+// ParseDate parses the string as a local date or a date and time.
+// When end is true, a date without a time returns the last moment of that day.
+func parseDate(s string, end bool) (time.Time, error) {
+	for _, layout := range []string{time.DateTime, "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, errDate
+	}
+	if end {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return t, nil
+}
+
+// Held returns the duration rounded to the minute in days, hours and minutes.
+func held(d time.Duration) string {
+	const day = 24 * time.Hour
+	d = d.Round(time.Minute)
+	days, d := d/day, d%day
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, d/time.Hour, d%time.Hour/time.Minute)
+	}
+	return fmt.Sprintf("%dh %dm", d/time.Hour, d%time.Hour/time.Minute)
+}
+
+// Archive appends the observations to the history file, unless the history is disabled.
+func (m modes) archive(obs ...watch.Observation) {
+	if m.noHistory {
+		return
+	}
+	name, err := history.File()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history: %s\n", err)
+		return
+	}
+	if err := history.Append(name, obs...); err != nil {
+		fmt.Fprintf(os.Stderr, "history: %s\n", err)
+	}
+}
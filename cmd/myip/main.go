@@ -4,29 +4,42 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"text/template"
+	"time"
 
-	"github.com/bengarrett/myip/pkg/ipv4"
-	"github.com/bengarrett/myip/pkg/ipv6"
+	"github.com/bengarrett/myip/pkg/config"
 	"github.com/bengarrett/myip/pkg/ping"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
 )
 
 type modes struct {
-	first   bool
-	ipv6    bool
-	raw     bool
-	timeout int64
+	both       bool
+	consensus  bool
+	first      bool
+	format     string
+	ipv6       bool
+	raw        bool
+	timeout    int64
+	tmpl       *template.Template
+	noHistory  bool
+	onChange   hook
+	maxAge     time.Duration
+	refresh    bool
+	background bool
+	hit        bool
+	registry   *provider.Registry
+	seen       *[]provider.Result
 }
 
-const (
-	// Default HTTP request timeout value in milliseconds.
-	httpTimeout = 5000
-	// Tabwriter padding using spaces.
-	padding = 4
-)
+// Tabwriter padding using spaces.
+const padding = 4
 
 var (
 	version = "0.0.0"
@@ -35,38 +48,49 @@ var (
 )
 
 func main() {
-	msInSec := func(i int) int {
-		const second = 1000
-		return i / second
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(configure(os.Args[2:]))
+		case "history":
+			os.Exit(recall(os.Args[2:]))
+		case "nat":
+			os.Exit(translate(os.Args[2:]))
+		case "watch":
+			os.Exit(watching(os.Args[2:]))
+		}
 	}
+	cfg := settings()
 	var mode modes
+	flag.BoolVar(&mode.background, "background", false,
+		"return a stale cached IP address immediately and refresh the cache in the background")
+	flag.BoolVar(&mode.both, "both", false, "query IPv4 and IPv6 concurrently and report the dual-stack connectivity")
+	flag.BoolVar(&mode.consensus, "consensus", false, "waits for every reply and returns the IP address most providers agree on")
 	flag.BoolVar(&mode.first, "first", false, "returns the first reported IP address and its location")
-	flag.BoolVar(&mode.ipv6, "ipv6", false, "return an IPv6 address instead of IPv4")
-	flag.BoolVar(&mode.raw, "simple", false, "simple mode only displays the IP address")
-	flag.Int64Var(&mode.timeout, "timeout", httpTimeout,
-		fmt.Sprintf("https request timeout in milliseconds (default: %d [%d seconds])", httpTimeout, msInSec(httpTimeout)))
+	flag.StringVar(&mode.format, "format", cfg.Format, "output format of the results, either text, json, ndjson, csv or tsv")
+	flag.BoolVar(&mode.ipv6, "ipv6", cfg.IPv6, "return an IPv6 address instead of IPv4")
+	flag.DurationVar(&mode.maxAge, "max-age", 0, "return the cached IP address when it is younger than the duration, for example 30s or 10m")
+	providersFlag(flag.CommandLine, &cfg)
+	providers := flag.String("providers-file", "", "add the custom providers defined in a JSON file")
+	flag.BoolVar(&mode.refresh, "refresh", false, "ignore the cached IP address and update the cache")
+	flag.BoolVar(&mode.raw, "simple", cfg.Simple, "simple mode only displays the IP address")
+	tmpl := flag.String("template", "", "output the results using a Go text/template, for example '{{.IP}} {{.Country}}'")
+	tmplFile := flag.String("template-file", "", "output the results using a Go text/template file")
+	flag.Int64Var(&mode.timeout, "timeout", cfg.Timeout,
+		timeoutUsage())
 	ver := flag.Bool("version", false, "version and information for this program")
+	flag.BoolVar(&mode.noHistory, "no-history", false, "do not append the IP address to the history file")
+	mode.onChange.flags(flag.CommandLine)
+	b := flag.Bool("b", false, "alias for both")
+	c := flag.Bool("c", false, "alias for consensus")
 	f := flag.Bool("f", false, "alias for first")
 	i := flag.Bool("i", false, "alias for ipv6")
 	s := flag.Bool("s", false, "alias for simple")
 	t := flag.Int64("t", 0, "alias for timeout")
 	v := flag.Bool("v", false, "alias for version")
 
-	flag.Usage = func() {
-		const alias = 1
-		fmt.Fprintln(os.Stderr, "MyIP Usage:")
-		fmt.Fprintln(os.Stderr, "    myip [options]:")
-		fmt.Fprintln(os.Stderr, "")
-		w := tabwriter.NewWriter(os.Stderr, 0, 0, padding, ' ', 0)
-		fmt.Fprintln(w, "    -h, --help\tshow this list of options")
-		flag.VisitAll(func(f *flag.Flag) {
-			if len(f.Name) == alias {
-				return
-			}
-			fmt.Fprintf(w, "    -%v, --%v\t%v\n", f.Name[:1], f.Name, f.Usage)
-		})
-		w.Flush()
-	}
+	flag.Usage = usage(flag.CommandLine, "myip [options]", "myip config show", "myip history [options]", "myip nat [options]",
+		"myip watch [options]")
 	flag.Parse()
 
 	// version information
@@ -74,6 +98,7 @@ func main() {
 		info()
 		return
 	}
+	mode.defaults(cfg, flag.CommandLine)
 	// aliases
 	if *i {
 		mode.ipv6 = true
@@ -87,58 +112,216 @@ func main() {
 	if *f {
 		mode.first = true
 	}
-	switch mode.ipv6 {
-	case true:
-		mode.parseIPv6()
-	case false:
-		mode.parseIPv4()
+	if *b {
+		mode.both = true
+	}
+	if *c {
+		mode.consensus = true
+	}
+	if !formats(mode.format) {
+		fmt.Fprintf(os.Stderr, "unknown format: %q\n", mode.format)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *tmpl != "" || *tmplFile != "" {
+		var err error
+		if mode.tmpl, err = parseTemplate(*tmpl, *tmplFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	var err error
+	if mode.registry, err = enabled(nil, cfg.Providers, *providers); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	mode.cached()
+	mode.seen = &[]provider.Result{}
+	ok := mode.parse()
+	if !mode.hit {
+		mode.store(*mode.seen)
+		obs := mode.observe(*mode.seen)
+		mode.archive(obs...)
+		mode.changed(context.Background(), obs...)
+	}
+	if !ok {
+		os.Exit(1)
 	}
 }
 
-func (m modes) parseIPv4() {
+// TimeoutUsage returns the usage of the timeout flag.
+func timeoutUsage() string {
+	const second = 1000
+	ms := config.Defaults().Timeout
+	return fmt.Sprintf("https request timeout in milliseconds (default: %d [%d seconds])",
+		ms, ms/second)
+}
+
+// Usage returns a function that prints the syntax and the flags of the set.
+func usage(fs *flag.FlagSet, syntax ...string) func() {
+	return func() {
+		const alias = 1
+		fmt.Fprintln(os.Stderr, "MyIP Usage:")
+		for _, s := range syntax {
+			fmt.Fprintf(os.Stderr, "    %s:\n", s)
+		}
+		fmt.Fprintln(os.Stderr, "")
+		w := tabwriter.NewWriter(os.Stderr, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(w, "    -h, --help\tshow this list of options")
+		aliases := map[string]string{}
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) == alias {
+				aliases[strings.TrimPrefix(f.Usage, "alias for ")] = f.Name
+			}
+		})
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) == alias {
+				return
+			}
+			if a, ok := aliases[f.Name]; ok {
+				fmt.Fprintf(w, "    -%v, --%v\t%v\n", a, f.Name, f.Usage)
+				return
+			}
+			fmt.Fprintf(w, "        --%v\t%v\n", f.Name, f.Usage)
+		})
+		w.Flush()
+	}
+}
+
+func (m modes) family() provider.Family {
+	if m.both {
+		return provider.Both
+	}
+	if m.ipv6 {
+		return provider.IPv6
+	}
+	return provider.IPv4
+}
+
+// Parse the modes, request the providers and print the results.
+// It returns false when the consensus mode fails to reach a quorum.
+func (m modes) parse() bool {
+	ctx := context.Background()
+	r := resolver.New(m.family(), time.Duration(m.timeout)*time.Millisecond)
+	r.Registry = m.registry
 	switch {
+	case m.tmpl != nil:
+		return m.render(ctx, r, m.tmpl)
+	case m.format == jsonf:
+		return m.json(ctx, r)
+	case m.format == ndjson:
+		return m.ndjson(ctx, r)
+	case m.format == csvf, m.format == tsv:
+		return m.table(ctx, r, m.format == tsv)
+	case m.both:
+		return m.dual(m.all(ctx, r))
+	case m.consensus:
+		return m.vote(m.all(ctx, r))
 	case m.first && m.raw:
-		ip := ipv4.One(m.timeout)
-		fmt.Println(ip)
-		return
+		res, _ := m.one(ctx, r)
+		fmt.Println(res.IP)
 	case m.first:
 		fmt.Print(ping.Zero1)
-		ip := ipv4.One(m.timeout)
-		s := ping.Sprint(ip)
-		fmt.Println(s)
-		return
+		res, err := m.one(ctx, r)
+		if err != nil {
+			fmt.Printf("\r(1/1) %s\n", err)
+			return true
+		}
+		fmt.Printf("\r(1/1) %s\n", res)
 	case m.raw:
-		ipv4.All(m.timeout, true)
+		m.print(r.Stream(ctx), r.Len())
 		fmt.Println()
-		return
 	default:
-		fmt.Print(ping.Zero)
-		ipv4.All(m.timeout, false)
+		fmt.Print(ping.Progress(0, r.Len(), "")[1:])
+		m.print(r.Stream(ctx), r.Len())
 		fmt.Println()
 	}
+	return true
+}
+
+// Vote prints the IP address that most providers agree on,
+// followed by a summary that names any dissenting or failed providers.
+// It returns false when there is no majority agreement.
+func (m modes) vote(results []provider.Result) bool {
+	c := resolver.Vote(results)
+	if c.IP != "" && m.raw {
+		fmt.Println(c.IP)
+	} else if c.IP != "" {
+		fmt.Println(c.Agree[0])
+	}
+	fmt.Printf("%d of %d agree", len(c.Agree), c.Total())
+	if len(c.Failed) > 0 {
+		fmt.Printf(" (%d failed)", len(c.Failed))
+	}
+	fmt.Println()
+	for _, r := range c.Dissent {
+		fmt.Printf("disagree: %s, %s\n", r.Provider, r)
+	}
+	for _, r := range c.Failed {
+		fmt.Printf("failed: %s, %s\n", r.Provider, failure(r))
+	}
+	if !c.Quorum() {
+		fmt.Println("no quorum: a majority of the providers do not agree")
+		return false
+	}
+	return true
 }
 
-func (m modes) parseIPv6() {
+// Dual prints the IPv4 and IPv6 addresses most providers agree on
+// and whether the IPv6 network is reachable.
+// It returns false when neither address family is reachable.
+func (m modes) dual(results []provider.Result) bool {
+	v4 := resolver.Vote(resolver.Filter(results, provider.IPv4))
+	v6 := resolver.Vote(resolver.Filter(results, provider.IPv6))
+	if m.raw {
+		for _, c := range []resolver.Consensus{v4, v6} {
+			if c.IP != "" {
+				fmt.Println(c.IP)
+			}
+		}
+		return v4.IP != "" || v6.IP != ""
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+	for _, x := range []struct {
+		name string
+		c    resolver.Consensus
+	}{
+		{"IPv4", v4},
+		{"IPv6", v6},
+	} {
+		if x.c.IP == "" {
+			fmt.Fprintf(w, "%s\tnone\t(%d failed)\n", x.name, len(x.c.Failed))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t(%d of %d agree)\n", x.name, x.c.Agree[0], len(x.c.Agree), x.c.Total())
+	}
+	w.Flush()
 	switch {
-	case m.first && m.raw:
-		ip := ipv6.One(m.timeout)
-		fmt.Println(ip)
-		return
-	case m.first:
-		fmt.Print(ping.Zero1)
-		ip := ipv6.One(m.timeout)
-		s := ping.Sprint(ip)
-		fmt.Println(s)
-		return
-	case m.raw:
-		ipv6.All(m.timeout, true)
-		fmt.Println()
-		return
+	case v4.IP != "" && v6.IP != "":
+		fmt.Println("dual-stack: IPv4 and IPv6 are reachable")
+	case v6.IP != "":
+		fmt.Println("IPv6 only: IPv4 is unreachable")
+	case v4.IP != "":
+		fmt.Println("IPv4 only: IPv6 is unreachable")
 	default:
-		fmt.Print(ping.Zero)
-		ipv6.All(m.timeout, false)
-		fmt.Println()
+		fmt.Println("no connection: IPv4 and IPv6 are unreachable")
+		return false
+	}
+	return true
+}
+
+// Failure returns the reason the provider result has no IP address.
+func failure(r provider.Result) string {
+	if r.Err != nil {
+		return r.Err.Error()
 	}
+	return "no ip address"
+}
+
+// Print the results as the replies come in.
+// Repeated IP addresses are printed on the same line.
+func (m modes) print(c <-chan provider.Result, total int) {
+	m.see(ping.Fprint(os.Stdout, c, total, m.raw)...)
 }
 
 func self() (string, error) {
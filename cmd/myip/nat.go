@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/stun"
+)
+
+// Default NAT behavior discovery timeout value in milliseconds.
+const natTimeout = 30000
+
+// Mapping is the NAT behavior discovery result for the json output format.
+type mapping struct {
+	Public    string `json:"public"`
+	Location  string `json:"location,omitempty"`
+	Local     string `json:"local"`
+	Type      string `json:"type"`
+	Mapping   string `json:"mapping"`
+	Filtering string `json:"filtering"`
+	Server    string `json:"server"`
+	Other     string `json:"other"`
+}
+
+// Translate runs the nat command using the arguments and returns the exit code.
+// It runs the RFC 5780 STUN behavior tests and prints the NAT mapping and filtering
+// behaviors alongside the public address.
+func translate(args []string) int {
+	fs := flag.NewFlagSet("nat", flag.ExitOnError)
+	format := fs.String("format", "text", "output format of the result, either text or json")
+	ipv6 := fs.Bool("ipv6", false, "test the IPv6 NAT instead of IPv4")
+	server := fs.String("server", stun.Stunprotocol, "STUN server that supports the RFC 5780 behavior discovery (default: "+stun.Stunprotocol+")")
+	timeout := fs.Int64("timeout", natTimeout, "timeout of the tests in milliseconds (default: 30000 [30 seconds])")
+	i := fs.Bool("i", false, "alias for ipv6")
+	t := fs.Int64("t", 0, "alias for timeout")
+	fs.Usage = usage(fs, "myip nat [options]")
+	_ = fs.Parse(args)
+	if *i {
+		*ipv6 = true
+	}
+	if *t > 0 {
+		*timeout = *t
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "format: %q: format is unknown, it must be either text or json\n", *format)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Millisecond)
+	defer cancel()
+	n, err := stun.Client{}.Detect(ctx, *server, *ipv6)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	m := mapping{
+		Public:    n.Mapped.String(),
+		Local:     n.Local.String(),
+		Type:      n.Type(),
+		Mapping:   n.Mapping.String(),
+		Filtering: n.Filtering.String(),
+		Server:    n.Server.String(),
+		Other:     n.Other.String(),
+	}
+	if l, err := geolite2.Lookup(n.Mapped.Addr().String()); err == nil {
+		m.Location = l.String()
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	public := m.Public
+	if m.Location != "" {
+		public += ", " + m.Location
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+	fmt.Fprintf(w, "public address\t%s\n", public)
+	fmt.Fprintf(w, "local address\t%s\n", m.Local)
+	fmt.Fprintf(w, "nat type\t%s\n", m.Type)
+	fmt.Fprintf(w, "mapping\t%s\n", m.Mapping)
+	fmt.Fprintf(w, "filtering\t%s\n", m.Filtering)
+	fmt.Fprintf(w, "stun server\t%s (other address %s)\n", m.Server, m.Other)
+	w.Flush()
+	return 0
+}
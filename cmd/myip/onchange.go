@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/notify"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+const (
+	// Default duration before an on-change command or a webhook is cancelled.
+	hookTimeout = 30 * time.Second
+	// Default number of retries of a failed webhook request.
+	hookRetries = 3
+)
+
+// Hook is the external command and the webhooks run when the IP address changes.
+type hook struct {
+	line     string
+	timeout  time.Duration
+	webhooks []string
+	wait     time.Duration
+	secret   string
+	retries  int
+	tmpl     *template.Template
+}
+
+// Flags defines the on-change flags in the set.
+func (h *hook) flags(fs *flag.FlagSet) {
+	fs.StringVar(&h.line, "on-change", "", "run a shell command whenever the IP address changes")
+	fs.DurationVar(&h.timeout, "on-change-timeout", hookTimeout,
+		fmt.Sprintf("duration before the on-change command is cancelled (default: %s)", hookTimeout))
+	fs.Func("webhook", "post a JSON payload to the URL whenever the IP address changes, it can be repeated",
+		func(s string) error {
+			h.webhooks = append(h.webhooks, s)
+			return nil
+		})
+	fs.IntVar(&h.retries, "webhook-retries", hookRetries,
+		fmt.Sprintf("retries of a failed webhook request (default: %d)", hookRetries))
+	fs.DurationVar(&h.wait, "webhook-timeout", hookTimeout,
+		fmt.Sprintf("duration before a webhook and all its retries are cancelled (default: %s)", hookTimeout))
+	fs.StringVar(&h.secret, "webhook-secret", "", "sign the webhook payloads using HMAC-SHA256 with the secret")
+	fs.Func("webhook-template", "replace the webhook payload with a Go text/template file, for example for a chat service",
+		func(name string) error {
+			b, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			h.tmpl, err = notify.BodyTemplate(string(b))
+			return err
+		})
+}
+
+// Enabled reports whether any on-change action is configured.
+func (m modes) enabled() bool {
+	return m.onChange.line != "" || len(m.onChange.webhooks) > 0
+}
+
+// Observe returns the observations of each address family in the results that has an address.
+func (m modes) observe(results []provider.Result) []watch.Observation {
+	obs := []watch.Observation{}
+	for _, f := range m.family().Families() {
+		if o := watch.Observe(results, f); o.IP != "" {
+			obs = append(obs, o)
+		}
+	}
+	return obs
+}
+
+// Changed compares the IP addresses of the observations with the state file.
+// For each address family with a different address, it runs the on-change actions.
+// On the first run without a state file, the actions run with an empty old address.
+// The addresses are then stored in the state file, along with any actions that
+// failed, which are kept pending so only they run again on the next comparison.
+func (m modes) changed(ctx context.Context, obs ...watch.Observation) {
+	if !m.enabled() {
+		return
+	}
+	name, err := notify.StateFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "on-change: %s\n", err)
+		return
+	}
+	state, err := notify.Load(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "on-change: %s\n", err)
+		return
+	}
+	for _, e := range state.Update(obs...) {
+		state.Defer(e, m.onChange.run(ctx, e)...)
+	}
+	if err := state.Save(name); err != nil {
+		fmt.Fprintf(os.Stderr, "on-change: %s\n", err)
+	}
+}
+
+// The action name of the on-change command, the webhooks are named by their URL.
+const commandAction = "command"
+
+// Run the on-change command and the webhooks that are pending for the event.
+// It returns the names of the actions that failed.
+func (h hook) run(ctx context.Context, e notify.Event) []string {
+	failed := []string{}
+	if h.line != "" && e.Runs(commandAction) && !h.command(ctx, e) {
+		failed = append(failed, commandAction)
+	}
+	for _, url := range h.webhooks {
+		if e.Runs(url) && !h.post(ctx, url, e) {
+			failed = append(failed, url)
+		}
+	}
+	return failed
+}
+
+// Command runs the on-change command and prints its exit status.
+// It returns false when the command fails to run or exits with a non-zero status.
+func (h hook) command(ctx context.Context, e notify.Event) bool {
+	if h.line == "" {
+		return true
+	}
+	c := notify.Command{Line: h.line, Timeout: h.timeout, Stdout: os.Stderr, Stderr: os.Stderr}
+	code, err := c.Run(ctx, e)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "on-change: %s\n", err)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "on-change: %s %s > %s, exit status %d\n", e.New.Family, e.Old.IP, e.New.IP, code)
+	return code == 0
+}
+
+// Post the event to the webhook URL and print the outcome.
+// It returns false when the webhook fails.
+func (h hook) post(ctx context.Context, url string, e notify.Event) bool {
+	if h.wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.wait)
+		defer cancel()
+	}
+	w := notify.Webhook{URL: url, Secret: h.secret, Template: h.tmpl, Retries: h.retries}
+	if err := w.Send(ctx, e); err != nil {
+		fmt.Fprintf(os.Stderr, "webhook: %s\n", err)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "webhook: %s %s > %s, sent to %s\n", e.New.Family, e.Old.IP, e.New.IP, url)
+	return true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+func TestModes_changed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a posix shell")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", dir)
+	var posts, fail atomic.Int32
+	fail.Store(1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		posts.Add(1)
+		if fail.Load() == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	out := filepath.Join(dir, "runs.txt")
+	m := modes{onChange: hook{
+		line:     `echo "$MYIP_OLD>$MYIP_NEW" >> ` + out,
+		timeout:  5 * time.Second,
+		webhooks: []string{srv.URL},
+		wait:     5 * time.Second,
+	}}
+	o := watch.Observation{Time: time.Now(), Family: provider.IPv4, IP: myiptest.IPv4}
+	runs := func() string {
+		b, _ := os.ReadFile(out)
+		return strings.TrimSpace(string(b))
+	}
+
+	// the first run has no previous address and the webhook fails
+	m.changed(context.Background(), o)
+	if got, want := runs(), ">"+myiptest.IPv4; got != want {
+		t.Errorf("first run command = %q, want %q", got, want)
+	}
+	if posts.Load() != 1 {
+		t.Errorf("first run webhook posts = %d, want 1", posts.Load())
+	}
+	// only the failed webhook is retried, the command is not run again
+	fail.Store(0)
+	m.changed(context.Background(), o)
+	if got, want := runs(), ">"+myiptest.IPv4; got != want {
+		t.Errorf("retry command = %q, want %q", got, want)
+	}
+	if posts.Load() != 2 {
+		t.Errorf("retry webhook posts = %d, want 2", posts.Load())
+	}
+	// nothing is pending or changed
+	m.changed(context.Background(), o)
+	if posts.Load() != 2 || strings.Count(runs(), "\n") != 0 {
+		t.Errorf("unchanged run = %d posts and %q, want no actions", posts.Load(), runs())
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+	"github.com/bengarrett/myip/pkg/tmplfunc"
+)
+
+var errTemplates = errors.New("use either the template or template-file option, not both")
+
+// Item is a provider result for use in a template.
+type item struct {
+	Provider string        // Provider is the name of the provider.
+	Family   string        // Family is the address family, either ipv4 or ipv6.
+	IP       string        // IP is the returned address.
+	City     string        // City is the city location of the IP.
+	Country  string        // Country is the country location of the IP.
+	ISOCode  string        // ISOCode is the two-character country code of the IP.
+	Latency  time.Duration // Latency is the time taken for the provider to reply.
+	Error    string        // Error is the provider request error.
+
+	Attributes provider.Attributes // Attributes are the details reported by the provider, such as org and asn.
+}
+
+func newItem(r provider.Result) item {
+	x := item{
+		Provider: r.Provider,
+		Family:   r.Family.String(),
+		IP:       r.IP,
+		City:     r.Location.City,
+		Country:  r.Location.Country,
+		ISOCode:  r.Location.ISOCode,
+		Latency:  r.Latency,
+
+		Attributes: r.Attributes,
+	}
+	if r.Err != nil {
+		x.Error = r.Err.Error()
+	}
+	return x
+}
+
+// View is the data passed to a template.
+// The embedded item is the first result of the address most providers agree on,
+// preferring IPv4 when both address families are requested.
+type view struct {
+	item
+	Agree     int       // Agree is the number of providers that returned the IP address.
+	Total     int       // Total is the number of providers requested for the IP family, including those that failed.
+	Quorum    bool      // Quorum is true when a majority of providers agree in every address family.
+	Providers []string  // Providers are the names of the providers that returned the IP address.
+	IPv4      string    // IPv4 is the IPv4 address most providers agree on.
+	IPv6      string    // IPv6 is the IPv6 address most providers agree on.
+	Time      string    // Time is the RFC 3339 time of the request.
+	Summaries []summary // Summaries are the consensus of each requested address family.
+	Results   []item    // Results are every provider result in the order the replies came in.
+}
+
+func newView(f provider.Family, results []provider.Result) view {
+	v := view{
+		Quorum:    true,
+		IPv4:      resolver.Vote(resolver.Filter(results, provider.IPv4)).IP,
+		IPv6:      resolver.Vote(resolver.Filter(results, provider.IPv6)).IP,
+		Time:      time.Now().Format(time.RFC3339),
+		Providers: []string{},
+		Summaries: []summary{},
+		Results:   []item{},
+	}
+	for _, x := range f.Families() {
+		c := resolver.Vote(resolver.Filter(results, x))
+		v.Summaries = append(v.Summaries, newSummary(x, results))
+		if !c.Quorum() {
+			v.Quorum = false
+		}
+		if v.IP == "" && len(c.Agree) > 0 {
+			v.item = newItem(c.Agree[0])
+			v.Agree, v.Total = len(c.Agree), c.Total()
+			for _, r := range c.Agree {
+				v.Providers = append(v.Providers, r.Provider)
+			}
+		}
+	}
+	for _, r := range results {
+		v.Results = append(v.Results, newItem(r))
+	}
+	return v
+}
+
+// Helpers are the functions available to a template,
+// the shared tmplfunc helpers and the ok and failed result filters.
+func helpers() template.FuncMap {
+	fm := tmplfunc.Map()
+	fm["ok"] = func(items []item) []item {
+		x := []item{}
+		for _, i := range items {
+			if i.Error == "" && i.IP != "" {
+				x = append(x, i)
+			}
+		}
+		return x
+	}
+	fm["failed"] = func(items []item) []item {
+		x := []item{}
+		for _, i := range items {
+			if i.Error != "" || i.IP == "" {
+				x = append(x, i)
+			}
+		}
+		return x
+	}
+	return fm
+}
+
+// Parse the template text or the content of the named template file.
+func parseTemplate(text, name string) (*template.Template, error) {
+	if text != "" && name != "" {
+		return nil, errTemplates
+	}
+	if name != "" {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("template file: %w", err)
+		}
+		text = string(b)
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return template.New("myip").Funcs(helpers()).Parse(text)
+}
+
+// Render prints the results using the template.
+// The first mode renders only the quickest successful reply.
+// It returns false when no provider returns an IP address, or
+// when the consensus mode fails to reach a quorum.
+func (m modes) render(ctx context.Context, r resolver.Resolver, tmpl *template.Template) bool {
+	v := newView(r.Family, m.results(ctx, r))
+	if err := tmpl.Execute(os.Stdout, v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return ok(m.consensus, v.Summaries)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+func TestNewView(t *testing.T) {
+	errFake := errors.New("fake error")
+	res := func(f provider.Family, n int, ip string) []provider.Result {
+		x := []provider.Result{}
+		for i := 0; i < n; i++ {
+			r := provider.Result{Provider: "p", Family: f, IP: ip}
+			if ip == "" {
+				r.Err = errFake
+			}
+			x = append(x, r)
+		}
+		return x
+	}
+	v4, v6 := res(provider.IPv4, 4, myiptest.IPv4), res(provider.IPv6, 4, myiptest.IPv6)
+	tests := []struct {
+		name    string
+		family  provider.Family
+		results []provider.Result
+		ip      string
+		agree   int
+		quorum  bool
+	}{
+		{"none", provider.IPv4, nil, "", 0, false},
+		{"ipv4", provider.IPv4, v4, myiptest.IPv4, 4, true},
+		{"ipv6", provider.IPv6, v6, myiptest.IPv6, 4, true},
+		{"both", provider.Both, append(append([]provider.Result{}, v4...), v6...), myiptest.IPv4, 4, true},
+		{"both ipv6 only", provider.Both, append(res(provider.IPv4, 4, ""), v6...), myiptest.IPv6, 4, false},
+		{"both ipv6 failed", provider.Both, append(append([]provider.Result{}, v4...), res(provider.IPv6, 4, "")...), myiptest.IPv4, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newView(tt.family, tt.results)
+			if v.IP != tt.ip {
+				t.Errorf("newView() IP = %q, want %q", v.IP, tt.ip)
+			}
+			if v.Agree != tt.agree {
+				t.Errorf("newView() Agree = %d, want %d", v.Agree, tt.agree)
+			}
+			if v.Quorum != tt.quorum {
+				t.Errorf("newView() Quorum = %v, want %v", v.Quorum, tt.quorum)
+			}
+			if got := ok(true, v.Summaries); got != tt.quorum {
+				t.Errorf("ok() = %v, want %v", got, tt.quorum)
+			}
+			if len(v.Summaries) != len(tt.family.Families()) {
+				t.Errorf("newView() = %d summaries, want %d", len(v.Summaries), len(tt.family.Families()))
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	v := newView(provider.IPv4, []provider.Result{
+		{Provider: "ipify", Family: provider.IPv4, IP: myiptest.IPv4},
+		{Provider: "seeip", Family: provider.IPv4, IP: myiptest.IPv4},
+		{Provider: "myipcom", Family: provider.IPv4, Err: errors.New("fake error")},
+	})
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"join", `{{join ", " .Providers}}`, "ipify, seeip\n"},
+		{"join pipe", `{{.Providers | join "+"}}`, "ipify+seeip\n"},
+		{"json", `{{json .IP}}`, `"192.0.2.1"` + "\n"},
+		{"default", `{{.City | default "unknown"}}`, "unknown\n"},
+		{"failed", `{{range failed .Results}}{{.Provider}}{{end}}`, "myipcom\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseTemplate(tt.text, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf strings.Builder
+			if err := tmpl.Execute(&buf, v); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
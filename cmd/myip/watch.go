@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/resolver"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+// Default duration between each watch poll.
+const watchInterval = 5 * time.Minute
+
+// Watching runs the watch command using the arguments and returns the exit code.
+// It polls the providers every interval and prints the IP address and location
+// whenever they change, until it receives an interrupt or terminate signal.
+func watching(args []string) int {
+	cfg := settings()
+	var mode modes
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	fs.BoolVar(&mode.both, "both", false, "watch both the IPv4 and IPv6 addresses")
+	interval := fs.Duration("interval", watchInterval, "duration between each poll of the providers, for example 30s, 5m or 1h")
+	fs.BoolVar(&mode.ipv6, "ipv6", cfg.IPv6, "watch an IPv6 address instead of IPv4")
+	providersFlag(fs, &cfg)
+	providers := fs.String("providers-file", "", "add the custom providers defined in a JSON file")
+	fs.BoolVar(&mode.raw, "simple", cfg.Simple, "simple mode only displays the IP address")
+	fs.Int64Var(&mode.timeout, "timeout", cfg.Timeout, timeoutUsage())
+	fs.BoolVar(&mode.noHistory, "no-history", false, "do not append the IP addresses to the history file")
+	b := fs.Bool("b", false, "alias for both")
+	i := fs.Bool("i", false, "alias for ipv6")
+	s := fs.Bool("s", false, "alias for simple")
+	t := fs.Int64("t", 0, "alias for timeout")
+	mode.onChange.flags(fs)
+	fs.Usage = usage(fs, "myip watch [options]")
+	_ = fs.Parse(args)
+	if *b {
+		mode.both = true
+	}
+	if *i {
+		mode.ipv6 = true
+	}
+	if *s {
+		mode.raw = true
+	}
+	if *t > 0 {
+		mode.timeout = *t
+	}
+
+	reg, err := enabled(keepAlive(*interval), cfg.Providers, *providers)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	w := watch.Watcher{
+		Resolver: resolver.Resolver{
+			Family:   mode.family(),
+			Timeout:  time.Duration(mode.timeout) * time.Millisecond,
+			Registry: reg,
+		},
+		Interval: *interval,
+		Tick: func(o watch.Observation) {
+			mode.archive(o)
+		},
+	}
+	err = w.Run(ctx, func(prev, next watch.Observation) {
+		mode.change(prev, next)
+		mode.changed(ctx, next)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// KeepAlive returns a HTTP client that keeps idle connections open between polls.
+func keepAlive(interval time.Duration) *http.Client {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultClient
+	}
+	t = t.Clone()
+	t.IdleConnTimeout = interval + time.Minute
+	return &http.Client{Transport: t}
+}
+
+// Change prints the time and the next IP address with its location.
+func (m modes) change(_, next watch.Observation) {
+	if m.raw {
+		fmt.Println(next.IP)
+		return
+	}
+	s := next.IP
+	if l := next.Location.String(); l != "" {
+		s += ", " + l
+	}
+	if m.both {
+		s = fmt.Sprintf("%s %s", next.Family, s)
+	}
+	fmt.Printf("%s %s\n", next.Time.Format(time.DateTime), s)
+}
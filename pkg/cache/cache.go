@@ -0,0 +1,225 @@
+// Package cache stores the provider results of each address family on disk,
+// so repeated lookups can be answered without requesting the providers.
+// © Ben Garrett https://github.com/bengarrett/myip
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+const (
+	// Link is the endpoint of the providers that replay the cached results.
+	Link     = "cache"
+	dirPerm  = 0o755
+	filePerm = 0o644
+)
+
+// Record is the cached result of a provider.
+type Record struct {
+	Provider string `json:"provider"`
+	IP       string `json:"ip,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	Attributes provider.Attributes `json:"attributes,omitempty"`
+}
+
+// Entry is the cached results of an address family.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Results []Record  `json:"results"`
+}
+
+// Age returns the time elapsed since the results were cached.
+func (e Entry) Age() time.Duration {
+	return time.Since(e.Time)
+}
+
+// Fresh reports whether the entry is younger than the max age.
+func (e Entry) Fresh(maxAge time.Duration) bool {
+	age := e.Age()
+	return age >= 0 && age < maxAge
+}
+
+// Cache is the entry of each address family and provider set, keyed by Key.
+type Cache map[string]Entry
+
+// Key returns the cache key of the address family and the names of the enabled providers.
+// The order of the names is ignored and no names returns the family name.
+func Key(f provider.Family, names []string) string {
+	if len(names) == 0 {
+		return f.String()
+	}
+	x := append([]string{}, names...)
+	sort.Strings(x)
+	return f.String() + " " + strings.Join(x, ",")
+}
+
+// File returns the path of the cache file in the user cache directory.
+func File() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "myip", "cache.json"), nil
+}
+
+// Load returns the cache stored in the named file.
+// A missing file returns an empty cache.
+func Load(name string) (Cache, error) {
+	c := Cache{}
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("cache file %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// Save writes the cache to the named file, creating any missing parent directories.
+// The file is replaced using a rename, so concurrent readers never see a partial write.
+func (c Cache) Save(name string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), dirPerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".cache-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(filePerm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Put stores the results of each address family requested from the named providers.
+// Families without a successful result are not stored, so a failed
+// lookup never replaces the previously cached addresses.
+func (c Cache) Put(names []string, results []provider.Result) {
+	for _, f := range provider.Both.Families() {
+		e, ok := Entry{Time: time.Now(), Results: []Record{}}, false
+		for _, r := range results {
+			if r.Family != f {
+				continue
+			}
+			rec := Record{Provider: r.Provider, IP: r.IP, Attributes: r.Attributes}
+			if r.Err != nil {
+				rec.IP, rec.Error, rec.Attributes = "", r.Err.Error(), nil
+			}
+			if r.OK() {
+				ok = true
+			}
+			e.Results = append(e.Results, rec)
+		}
+		if ok {
+			c[Key(f, names)] = e
+		}
+	}
+}
+
+// Get returns the entries of the address family, or families, of the named providers.
+// It returns false when any of the families are not cached.
+func (c Cache) Get(f provider.Family, names []string) ([]Entry, bool) {
+	entries := []Entry{}
+	for _, x := range f.Families() {
+		e, ok := c[Key(x, names)]
+		if !ok {
+			return nil, false
+		}
+		entries = append(entries, e)
+	}
+	return entries, len(entries) > 0
+}
+
+// Fresh reports whether every entry of the address family, or families,
+// of the named providers is cached and younger than the max age.
+func (c Cache) Fresh(f provider.Family, names []string, maxAge time.Duration) bool {
+	entries, ok := c.Get(f, names)
+	if !ok {
+		return false
+	}
+	for _, e := range entries {
+		if !e.Fresh(maxAge) {
+			return false
+		}
+	}
+	return true
+}
+
+// Registry returns a registry of providers that instantly reply with
+// the cached results of the address family, or families, of the named providers.
+func (c Cache) Registry(f provider.Family, names []string) *provider.Registry {
+	services, order := map[string]*provider.Service{}, []string{}
+	for _, x := range f.Families() {
+		for _, rec := range c[Key(x, names)].Results {
+			s, ok := services[rec.Provider]
+			if !ok {
+				s = &provider.Service{ID: rec.Provider}
+				services[rec.Provider] = s
+				order = append(order, rec.Provider)
+			}
+			switch x {
+			case provider.IPv4:
+				s.Linkv4, s.IPv4, s.Detailv4 = Link, replay(rec), detail(rec)
+			case provider.IPv6:
+				s.Linkv6, s.IPv6, s.Detailv6 = Link, replay(rec), detail(rec)
+			}
+		}
+	}
+	r := provider.NewRegistry()
+	for _, name := range order {
+		_ = r.Register(*services[name])
+	}
+	return r
+}
+
+// Replay returns a request that replies with the cached record.
+func replay(rec Record) provider.Request {
+	return func(_ context.Context, cancel context.CancelFunc) (string, error) {
+		defer cancel()
+		if rec.Error != "" {
+			return "", errors.New(rec.Error) //nolint: goerr113
+		}
+		return rec.IP, nil
+	}
+}
+
+// Detail returns a request that replies with the cached record and its attributes.
+func detail(rec Record) provider.Detail {
+	request := replay(rec)
+	return func(ctx context.Context, cancel context.CancelFunc) (string, provider.Attributes, error) {
+		ip, err := request(ctx, cancel)
+		if err != nil {
+			return "", nil, err
+		}
+		return ip, rec.Attributes, nil
+	}
+}
@@ -0,0 +1,129 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/cache"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+)
+
+var errTimeout = errors.New("ipify.org: timeout") //nolint: gochecknoglobals
+
+func results() []provider.Result {
+	return []provider.Result{
+		{Provider: "ipify", Family: provider.IPv4, Err: errTimeout},
+		{Provider: "seeip", Family: provider.IPv4, IP: "192.0.2.1"},
+		{Provider: "seeip", Family: provider.IPv6, IP: "2001:db8::1"},
+		{Provider: "myipcom", Family: provider.IPv4, IP: "192.0.2.1",
+			Attributes: provider.Attributes{provider.AttrCountryCode: "AU"}},
+	}
+}
+
+func TestCache(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "myip", "cache.json")
+	c, err := cache.Load(name)
+	if err != nil || len(c) != 0 {
+		t.Fatalf("Load() = %v, %v, want an empty cache", c, err)
+	}
+	if c.Fresh(provider.IPv4, nil, time.Hour) {
+		t.Error("Fresh() = true, want false for an empty cache")
+	}
+	c.Put(nil, results())
+	c.Put(nil, []provider.Result{{Provider: "seeip", Family: provider.IPv6, Err: errTimeout}})
+	if err := c.Save(name); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	c, err = cache.Load(name)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if n := len(c["ipv4"].Results); n != 3 {
+		t.Errorf("Load() ipv4 results = %d, want 3", n)
+	}
+	if ip := c["ipv6"].Results[0].IP; ip != "2001:db8::1" {
+		t.Errorf("Load() ipv6 = %q, want a failed lookup to keep the cached address", ip)
+	}
+	tests := []struct {
+		name   string
+		f      provider.Family
+		maxAge time.Duration
+		want   bool
+	}{
+		{"ipv4", provider.IPv4, time.Hour, true},
+		{"both", provider.Both, time.Hour, true},
+		{"stale", provider.IPv4, time.Nanosecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Fresh(tt.f, nil, tt.maxAge); got != tt.want {
+				t.Errorf("Fresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	delete(c, "ipv6")
+	if _, ok := c.Get(provider.Both, nil); ok {
+		t.Error("Get() = true, want false when a family is missing")
+	}
+}
+
+func TestCache_Registry(t *testing.T) {
+	c := cache.Cache{}
+	c.Put(nil, results())
+	reg := c.Registry(provider.Both, nil)
+	if n := reg.Len(); n != 3 {
+		t.Errorf("Registry() providers = %d, want 3", n)
+	}
+	r := resolver.Resolver{Family: provider.Both, Timeout: time.Second, Registry: reg}
+	if n := r.Len(); n != 4 {
+		t.Errorf("Registry() requests = %d, want 4", n)
+	}
+	ok, failed := 0, 0
+	for _, x := range r.Results(context.Background()) {
+		switch {
+		case x.Err != nil:
+			failed++
+			if x.Err.Error() != errTimeout.Error() {
+				t.Errorf("Results() error = %v, want %v", x.Err, errTimeout)
+			}
+		case x.IP != "":
+			ok++
+		}
+		if x.Provider == "myipcom" && x.Attributes[provider.AttrCountryCode] != "AU" {
+			t.Errorf("Results() myipcom attributes = %v, want the cached country code", x.Attributes)
+		}
+	}
+	if ok != 3 || failed != 1 {
+		t.Errorf("Results() = %d ok and %d failed, want 3 and 1", ok, failed)
+	}
+}
+
+func TestKey(t *testing.T) {
+	c := cache.Cache{}
+	c.Put([]string{"seeip", "ipify", "myipcom"}, results())
+	tests := []struct {
+		name  string
+		names []string
+		want  bool
+	}{
+		{"same", []string{"seeip", "ipify", "myipcom"}, true},
+		{"reordered", []string{"ipify", "myipcom", "seeip"}, true},
+		{"fewer", []string{"ipify", "seeip"}, false},
+		{"more", []string{"ipify", "myipcom", "seeip", "stun"}, false},
+		{"none", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Fresh(provider.IPv4, tt.names, time.Hour); got != tt.want {
+				t.Errorf("Fresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got, want := cache.Key(provider.IPv6, []string{"seeip", "ipify"}), "ipv6 ipify,seeip"; got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+}
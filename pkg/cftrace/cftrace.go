@@ -0,0 +1,257 @@
+// Package cftrace returns your Internet-facing IPv4 or IPv6 address,
+// sourced from the Cloudflare trace of the 1.1.1.1 resolver.
+// The trace also reports the location, data center, TLS version
+// and whether the Cloudflare WARP client is active.
+// https://www.cloudflare.com/cdn-cgi/trace
+// © Ben Garrett https://github.com/bengarrett/myip
+package cftrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+// https://1.1.1.1/cdn-cgi/trace
+//
+// Output:
+// fl=29f21
+// h=1.1.1.1
+// ip=1.1.1.1
+// ts=1650000000.000
+// visit_scheme=https
+// uag=Go-http-client/1.1
+// colo=SYD
+// sliver=none
+// http=http/1.1
+// loc=AU
+// tls=TLSv1.3
+// sni=off
+// warp=off
+// gateway=off
+// rbi=off
+// kex=X25519
+
+var (
+	ErrNoIP    = errors.New("ip address is empty")
+	ErrInvalid = errors.New("ip address is invalid")
+	ErrNoIPv4  = errors.New("ip address is not ipv4")
+	ErrNoIPv6  = errors.New("ip address is not ipv6")
+	ErrStatus  = errors.New("unusual cloudflare.com server response")
+)
+
+const (
+	domain = "cloudflare.com"
+	Linkv4 = "https://1.1.1.1/cdn-cgi/trace"
+	Linkv6 = "https://[2606:4700:4700::1111]/cdn-cgi/trace"
+)
+
+// Trace is the parsed response of a Cloudflare trace.
+type Trace struct {
+	IP     string            // IP is the client IP address.
+	Loc    string            // Loc is the ISO 3166-1 country code of the client location.
+	Colo   string            // Colo is the IATA airport code of the Cloudflare data center.
+	Warp   string            // Warp is the Cloudflare WARP status, either off, on or plus.
+	TLS    string            // TLS is the TLS version of the connection.
+	Fields map[string]string // Fields are every key and value pair of the trace.
+}
+
+// WARP reports whether the request was made through Cloudflare WARP.
+func (t Trace) WARP() bool {
+	return t.Warp == "on" || t.Warp == "plus"
+}
+
+// Parse returns the trace of the newline separated key=value pairs in the body.
+// Lines without a key=value pair are ignored.
+func Parse(b []byte) (Trace, error) {
+	t := Trace{Fields: map[string]string{}}
+	for _, line := range strings.Split(string(b), "\n") {
+		key, val, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || key == "" {
+			continue
+		}
+		t.Fields[key] = val
+	}
+	t.IP = t.Fields["ip"]
+	t.Loc = t.Fields["loc"]
+	t.Colo = t.Fields["colo"]
+	t.Warp = t.Fields["warp"]
+	t.TLS = t.Fields["tls"]
+	if t.IP == "" {
+		return t, ErrNoIP
+	}
+	return t, nil
+}
+
+// Client requests the Cloudflare trace using a HTTP client and endpoint URLs.
+// The zero value uses http.DefaultClient with the Linkv4 and Linkv6 URLs.
+type Client struct {
+	HTTP   *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Linkv4 string       // Linkv4 is the IPv4 endpoint URL, an empty value uses Linkv4.
+	Linkv6 string       // Linkv6 is the IPv6 endpoint URL, an empty value uses Linkv6.
+}
+
+func (c Client) client() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
+
+// URLs returns the IPv4 and IPv6 endpoint URLs used by the client.
+func (c Client) URLs() (string, string) {
+	v4, v6 := c.Linkv4, c.Linkv6
+	if v4 == "" {
+		v4 = Linkv4
+	}
+	if v6 == "" {
+		v6 = Linkv6
+	}
+	return v4, v6
+}
+
+// IPv4 returns the clients online IP address.
+func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return Client{}.IPv4(ctx, cancel)
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return Client{}.IPv6(ctx, cancel)
+}
+
+// Request the Cloudflare trace and return a valid IPv4 or IPv6 address.
+func Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	return Client{}.Request(ctx, cancel, url)
+}
+
+// RequestT requests the Cloudflare trace and returns the parsed trace.
+func RequestT(ctx context.Context, cancel context.CancelFunc, url string) (Trace, error) {
+	return Client{}.RequestT(ctx, cancel, url)
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	t, err := c.TraceV4(ctx, cancel)
+	if err != nil {
+		return "", err
+	}
+	return t.IP, nil
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	t, err := c.TraceV6(ctx, cancel)
+	if err != nil {
+		return "", err
+	}
+	return t.IP, nil
+}
+
+// TraceV4 returns the trace of the clients online IP address.
+func (c Client) TraceV4(ctx context.Context, cancel context.CancelFunc) (Trace, error) {
+	v4, _ := c.URLs()
+	return c.trace(ctx, cancel, v4, false)
+}
+
+// TraceV6 returns the trace of the clients online IP address. Using this
+// on a network that does not support IPv6 will result in an error.
+func (c Client) TraceV6(ctx context.Context, cancel context.CancelFunc) (Trace, error) {
+	_, v6 := c.URLs()
+	return c.trace(ctx, cancel, v6, true)
+}
+
+// trace requests the Cloudflare trace and returns the parsed trace
+// containing an IP address of the family.
+func (c Client) trace(ctx context.Context, cancel context.CancelFunc, url string, ipv6 bool) (Trace, error) {
+	t, err := c.RequestT(ctx, cancel, url)
+	if err != nil {
+		return t, err
+	}
+	if err := Family(ipv6, t.IP); err != nil {
+		return t, fault.Wrap(domain, fault.Validation, err)
+	}
+	return t, nil
+}
+
+// Request the Cloudflare trace and return a valid IPv4 or IPv6 address.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	t, err := c.RequestT(ctx, cancel, url)
+	if err != nil {
+		return "", err
+	}
+	return t.IP, nil
+}
+
+// RequestT requests the Cloudflare trace and returns the parsed trace
+// containing a valid IPv4 or IPv6 address.
+func (c Client) RequestT(ctx context.Context, cancel context.CancelFunc, url string) (Trace, error) {
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Trace{}, fault.New(domain, err)
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return Trace{}, fault.New(domain, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Trace{}, fault.Wrap(domain, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Trace{}, fault.Wrap(domain, fault.Parse, err)
+	}
+
+	t, err := Parse(b)
+	if err != nil {
+		return t, fault.Wrap(domain, fault.Parse, err)
+	}
+	if err := Valid(t.IP); err != nil {
+		return t, fault.Wrap(domain, fault.Validation, err)
+	}
+
+	return t, nil
+}
+
+// Valid returns nil if ip is a valid textual representation of an IP address.
+func Valid(ip string) error {
+	if ip == "" {
+		return ErrNoIP
+	}
+	if net.ParseIP(ip) == nil {
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+// Family returns nil if ip is a valid IPv6 address when ipv6 is true,
+// or a valid IPv4 address when ipv6 is false.
+func Family(ipv6 bool, ip string) error {
+	if err := Valid(ip); err != nil {
+		return err
+	}
+	v4 := net.ParseIP(ip).To4() != nil
+	switch {
+	case ipv6 && v4:
+		return ErrNoIPv6
+	case !ipv6 && !v4:
+		return ErrNoIPv4
+	}
+
+	return nil
+}
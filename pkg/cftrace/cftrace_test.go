@@ -0,0 +1,116 @@
+package cftrace_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/cftrace"
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/myiptest"
+)
+
+// ExampleRequestT demonstrates a Cloudflare trace request with a 5 second timeout.
+func ExampleRequestT() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t, err := cftrace.RequestT(ctx, cancel, cftrace.Linkv4)
+	if err != nil {
+		log.Printf("\n%s\n", err)
+	}
+	fmt.Println(t.IP, t.Loc, t.WARP())
+}
+
+func TestTimeout(t *testing.T) {
+	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
+	if _, err := cftrace.IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    cftrace.Trace
+		warp    bool
+		wantErr error
+	}{
+		{"trace", myiptest.Body(myiptest.CFTrace, false),
+			cftrace.Trace{IP: myiptest.IPv4, Loc: "AU", Colo: "SYD", Warp: "on", TLS: "TLSv1.3"}, true, nil},
+		{"crlf", "ip=2001:db8::1\r\nwarp=off\r\n",
+			cftrace.Trace{IP: myiptest.IPv6, Warp: "off"}, false, nil},
+		{"plus", "ip=192.0.2.1\nwarp=plus\n", cftrace.Trace{IP: myiptest.IPv4, Warp: "plus"}, true, nil},
+		{"no ip", "fl=29f21\nh=1.1.1.1\n", cftrace.Trace{}, false, cftrace.ErrNoIP},
+		{"html", "<html><body>malformed</body></html>", cftrace.Trace{}, false, cftrace.ErrNoIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cftrace.Parse([]byte(tt.body))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.IP != tt.want.IP || got.Loc != tt.want.Loc || got.Colo != tt.want.Colo ||
+				got.Warp != tt.want.Warp || got.TLS != tt.want.TLS {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			if got.WARP() != tt.warp {
+				t.Errorf("Parse() WARP() = %v, want %v", got.WARP(), tt.warp)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.OK, true, myiptest.IPv6, nil},
+		{"malformed", myiptest.Malformed, false, "", cftrace.ErrNoIP},
+		{"status", myiptest.Status, false, "", cftrace.ErrStatus},
+		{"wrong family ipv4", myiptest.WrongFamily, false, "", cftrace.ErrNoIPv4},
+		{"wrong family ipv6", myiptest.WrongFamily, true, "", cftrace.ErrNoIPv6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.CFTrace, tt.behavior)
+			defer srv.Close()
+			c := cftrace.Client{HTTP: srv.Client(), Linkv4: srv.Linkv4, Linkv6: srv.Linkv6}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.behavior == myiptest.WrongFamily && !fault.Is(err, fault.Validation) {
+				t.Errorf("Client() error = %v, want %v", err, fault.Validation)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+	srv := myiptest.NewServer(myiptest.CFTrace, myiptest.OK)
+	defer srv.Close()
+	c := cftrace.Client{HTTP: srv.Client()}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	tr, err := c.RequestT(ctx, cancel, srv.Linkv4)
+	if err != nil || tr.Colo != "SYD" || tr.Fields["gateway"] != "off" || !tr.WARP() {
+		t.Errorf("RequestT() = %+v, %v, want the trace fields", tr, err)
+	}
+	if v4, v6 := (cftrace.Client{}).URLs(); v4 != cftrace.Linkv4 || v6 != cftrace.Linkv6 {
+		t.Errorf("URLs() = %v, %v, want %v, %v", v4, v6, cftrace.Linkv4, cftrace.Linkv6)
+	}
+}
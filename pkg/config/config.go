@@ -0,0 +1,233 @@
+// Package config loads the default settings of myip from a JSON
+// configuration file and the MYIP_* environment variables.
+// © Ben Garrett https://github.com/bengarrett/myip
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+)
+
+var (
+	ErrMode    = errors.New("mode is unknown, it must be either all, first, consensus or both")
+	ErrTimeout = errors.New("timeout must be greater than zero")
+)
+
+// Modes of the lookup.
+const (
+	All       = "all"       // All prints the replies of every provider.
+	First     = "first"     // First prints the quickest reply.
+	Consensus = "consensus" // Consensus prints the IP address most providers agree on.
+	Both      = "both"      // Both queries the IPv4 and IPv6 addresses.
+)
+
+// Sources of a setting, in the order of precedence from lowest to highest.
+const (
+	Default = "default"     // Default is the built-in value.
+	File    = "config file" // File is the configuration file.
+	Env     = "environment" // Env is an environment variable.
+)
+
+// Environment variables that override the configuration file.
+const (
+	EnvFile      = "MYIP_CONFIG"    // EnvFile is the path of the configuration file.
+	EnvMode      = "MYIP_MODE"      // EnvMode is the default mode.
+	EnvIPv6      = "MYIP_IPV6"      // EnvIPv6 uses IPv6 instead of IPv4.
+	EnvSimple    = "MYIP_SIMPLE"    // EnvSimple only displays the IP address.
+	EnvTimeout   = "MYIP_TIMEOUT"   // EnvTimeout is the request timeout in milliseconds.
+	EnvFormat    = "MYIP_FORMAT"    // EnvFormat is the output format.
+	EnvLanguage  = "MYIP_LANGUAGE"  // EnvLanguage is the geolocation language.
+	EnvProviders = "MYIP_PROVIDERS" // EnvProviders is a comma separated list of the enabled providers.
+)
+
+// Default request timeout in milliseconds.
+const timeout = 5000
+
+// Config is the default settings of myip.
+type Config struct {
+	Mode      string   `json:"mode"`      // Mode is either all, first, consensus or both.
+	IPv6      bool     `json:"ipv6"`      // IPv6 uses IPv6 instead of IPv4.
+	Simple    bool     `json:"simple"`    // Simple only displays the IP address.
+	Timeout   int64    `json:"timeout"`   // Timeout of each request in milliseconds.
+	Format    string   `json:"format"`    // Format is the output format.
+	Language  string   `json:"language"`  // Language is the locale code of the location names.
+	Providers []string `json:"providers"` // Providers are the names of the enabled providers, empty uses the defaults.
+}
+
+// Sources is the source of each setting, keyed by the JSON name.
+type Sources map[string]string
+
+// Defaults returns the built-in settings.
+func Defaults() Config {
+	return Config{
+		Mode:      All,
+		Timeout:   timeout,
+		Format:    "text",
+		Language:  "en",
+		Providers: []string{},
+	}
+}
+
+// Path returns the path of the configuration file.
+// It uses the MYIP_CONFIG environment variable when set, otherwise
+// config.json in the myip directory of the user configuration directory.
+func Path() (string, error) {
+	if name := os.Getenv(EnvFile); name != "" {
+		return name, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "myip", "config.json"), nil
+}
+
+// Load returns the settings with the named configuration file and
+// then the environment variables applied over the defaults.
+// A missing configuration file is ignored.
+func Load(name string) (Config, Sources, error) {
+	c, src := Defaults(), Sources{}
+	for _, key := range keys() {
+		src[key] = Default
+	}
+	b, err := os.ReadFile(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return c, src, err
+	}
+	if err == nil {
+		if err := c.decode(b, src); err != nil {
+			return c, src, fmt.Errorf("config file %s: %w", name, err)
+		}
+	}
+	if err := c.env(src); err != nil {
+		return c, src, err
+	}
+	return c, src, c.Validate()
+}
+
+// decode applies the JSON settings over the config and records their source.
+func (c *Config) decode(b []byte, src Sources) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(c); err != nil {
+		return err
+	}
+	for key := range fields {
+		src[key] = File
+	}
+	return nil
+}
+
+// env applies the environment variables over the config and records their source.
+func (c *Config) env(src Sources) error {
+	for key, name := range map[string]string{
+		"mode":      EnvMode,
+		"ipv6":      EnvIPv6,
+		"simple":    EnvSimple,
+		"timeout":   EnvTimeout,
+		"format":    EnvFormat,
+		"language":  EnvLanguage,
+		"providers": EnvProviders,
+	} {
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := c.set(key, val); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		src[key] = Env
+	}
+	return nil
+}
+
+// set parses the value of the named setting.
+func (c *Config) set(key, val string) error {
+	var err error
+	switch key {
+	case "mode":
+		c.Mode = val
+	case "ipv6":
+		c.IPv6, err = strconv.ParseBool(val)
+	case "simple":
+		c.Simple, err = strconv.ParseBool(val)
+	case "timeout":
+		c.Timeout, err = strconv.ParseInt(val, 10, 64)
+	case "format":
+		c.Format = val
+	case "language":
+		c.Language = val
+	case "providers":
+		c.Providers = List(val)
+	}
+	return err
+}
+
+// List returns the names in the comma separated list, skipping any empty names.
+func List(s string) []string {
+	names := []string{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate returns an error when the mode, timeout or language is invalid.
+// The output format and providers are validated by the caller.
+func (c Config) Validate() error {
+	switch c.Mode {
+	case All, First, Consensus, Both:
+	default:
+		return fmt.Errorf("%q: %w", c.Mode, ErrMode)
+	}
+	if c.Timeout <= 0 {
+		return ErrTimeout
+	}
+	for _, l := range geolite2.Languages() {
+		if strings.EqualFold(l, c.Language) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q: %w", c.Language, geolite2.ErrLanguage)
+}
+
+// Values returns the JSON name and value of each setting, sorted by name.
+func (c Config) Values() [][2]string {
+	vals := [][2]string{}
+	b, _ := json.Marshal(c)
+	fields := map[string]json.RawMessage{}
+	_ = json.Unmarshal(b, &fields)
+	for _, key := range keys() {
+		vals = append(vals, [2]string{key, string(fields[key])})
+	}
+	return vals
+}
+
+// keys returns the sorted JSON names of the settings.
+func keys() []string {
+	b, _ := json.Marshal(Config{})
+	fields := map[string]json.RawMessage{}
+	_ = json.Unmarshal(b, &fields)
+	names := make([]string, 0, len(fields))
+	for key := range fields {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/config"
+	"github.com/bengarrett/myip/pkg/geolite2"
+)
+
+func write(t *testing.T, s string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(name, []byte(s), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLoad(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "config.json")
+	c, src, err := config.Load(missing)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Mode != config.All || c.Timeout != 5000 || src["mode"] != config.Default {
+		t.Errorf("Load() = %+v, %v, want the defaults", c, src)
+	}
+
+	name := write(t, `{"mode": "consensus", "timeout": 2000, "providers": ["ipify", "seeip"]}`)
+	t.Setenv(config.EnvTimeout, "3000")
+	t.Setenv(config.EnvProviders, "myipcom, ")
+	c, src, err = config.Load(name)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Mode != config.Consensus || src["mode"] != config.File {
+		t.Errorf("Load() mode = %q from %s, want consensus from the file", c.Mode, src["mode"])
+	}
+	if c.Timeout != 3000 || src["timeout"] != config.Env {
+		t.Errorf("Load() timeout = %d from %s, want the environment to override the file", c.Timeout, src["timeout"])
+	}
+	if strings.Join(c.Providers, ",") != "myipcom" || src["providers"] != config.Env {
+		t.Errorf("Load() providers = %v from %s, want myipcom", c.Providers, src["providers"])
+	}
+	if c.Format != "text" || src["format"] != config.Default {
+		t.Errorf("Load() format = %q from %s, want the default", c.Format, src["format"])
+	}
+}
+
+func TestLoad_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		env  [2]string
+		want error
+	}{
+		{"mode", `{"mode": "fastest"}`, [2]string{}, config.ErrMode},
+		{"timeout", `{}`, [2]string{config.EnvTimeout, "0"}, config.ErrTimeout},
+		{"language", `{"language": "xx"}`, [2]string{}, geolite2.ErrLanguage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env[0] != "" {
+				t.Setenv(tt.env[0], tt.env[1])
+			}
+			if _, _, err := config.Load(write(t, tt.json)); !errors.Is(err, tt.want) {
+				t.Errorf("Load() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if _, _, err := config.Load(write(t, `{"colour": true}`)); err == nil {
+		t.Error("Load() error = nil, want an unknown field error")
+	}
+	t.Setenv(config.EnvIPv6, "maybe")
+	if _, _, err := config.Load(write(t, `{}`)); err == nil {
+		t.Error("Load() error = nil, want an invalid boolean error")
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv(config.EnvFile, "/tmp/myip.json")
+	if name, err := config.Path(); err != nil || name != "/tmp/myip.json" {
+		t.Errorf("Path() = %v, %v, want %v", name, err, "/tmp/myip.json")
+	}
+}
+
+func TestConfig_Values(t *testing.T) {
+	vals := config.Defaults().Values()
+	if len(vals) != 7 || vals[0][0] != "format" || vals[0][1] != `"text"` {
+		t.Errorf("Values() = %v, want the sorted settings", vals)
+	}
+}
@@ -0,0 +1,269 @@
+// Package custom defines additional providers from a JSON file,
+// so any plain text, JSON, key=value or other HTTP endpoint
+// that replies with an IP address can join the built-in providers.
+// © Ben Garrett https://github.com/bengarrett/myip
+package custom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+var (
+	ErrDuplicate = errors.New("provider name is already defined")
+	ErrField     = errors.New("field is not found in the response")
+	ErrInvalid   = errors.New("ip address is invalid")
+	ErrName      = errors.New("provider name is empty")
+	ErrNoIP      = errors.New("ip address is empty")
+	ErrNoIPv4    = errors.New("ip address is not ipv4")
+	ErrNoIPv6    = errors.New("ip address is not ipv6")
+	ErrParser    = errors.New("parser is unknown, it must be either text, json, kv or regex")
+	ErrPattern   = errors.New("regex pattern must be set")
+	ErrStatus    = errors.New("unusual server response")
+	ErrURL       = errors.New("provider needs an ipv4 or ipv6 url")
+)
+
+// Parsers of the response body.
+const (
+	Text  = "text"  // Text is a body containing only the IP address.
+	JSON  = "json"  // JSON is an object with the IP address in the field path, for example "ip" or "data.address".
+	KV    = "kv"    // KV is lines of key=value or key: value pairs with the IP address in the field key.
+	Regex = "regex" // Regex is a body matching the field pattern, with the IP address in the first group.
+)
+
+// Definition is a provider defined in a providers file.
+type Definition struct {
+	Name   string `json:"name"`   // Name of the provider.
+	IPv4   string `json:"ipv4"`   // IPv4 is the URL that replies with an IPv4 address.
+	IPv6   string `json:"ipv6"`   // IPv6 is the URL that replies with an IPv6 address.
+	Parser string `json:"parser"` // Parser of the response body, an empty value uses text.
+	Field  string `json:"field"`  // Field is the JSON path, the key or the regex pattern of the IP address.
+}
+
+// Load returns the definitions in the named providers file,
+// which contains a JSON array of definitions.
+func Load(name string) ([]Definition, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defs := []Definition{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&defs); err != nil {
+		return nil, fmt.Errorf("providers file %s: %w", name, err)
+	}
+	names := map[string]bool{}
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return nil, fmt.Errorf("providers file %s: %w", name, err)
+		}
+		if names[def.Name] {
+			return nil, fmt.Errorf("providers file %s: %q: %w", name, def.Name, ErrDuplicate)
+		}
+		names[def.Name] = true
+	}
+	return defs, nil
+}
+
+// Validate returns an error when the name, URLs, parser or field of the definition are invalid.
+func (d Definition) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return ErrName
+	}
+	if d.IPv4 == "" && d.IPv6 == "" {
+		return fmt.Errorf("%s: %w", d.Name, ErrURL)
+	}
+	for _, link := range []string{d.IPv4, d.IPv6} {
+		if link == "" {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: %q: %w", d.Name, link, ErrURL)
+		}
+	}
+	switch d.Parser {
+	case "", Text, JSON, KV:
+	case Regex:
+		if d.Field == "" {
+			return fmt.Errorf("%s: %w", d.Name, ErrPattern)
+		}
+		if _, err := regexp.Compile(d.Field); err != nil {
+			return fmt.Errorf("%s: %w", d.Name, err)
+		}
+	default:
+		return fmt.Errorf("%s: %q: %w", d.Name, d.Parser, ErrParser)
+	}
+	return nil
+}
+
+// Service returns the definition as a provider using the HTTP client,
+// a nil client uses http.DefaultClient.
+func (d Definition) Service(c *http.Client) provider.Service {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	s := provider.Service{ID: d.Name, Linkv4: d.IPv4, Linkv6: d.IPv6}
+	if d.IPv4 != "" {
+		s.IPv4 = func(ctx context.Context, cancel context.CancelFunc) (string, error) {
+			return d.request(ctx, cancel, c, provider.IPv4)
+		}
+	}
+	if d.IPv6 != "" {
+		s.IPv6 = func(ctx context.Context, cancel context.CancelFunc) (string, error) {
+			return d.request(ctx, cancel, c, provider.IPv6)
+		}
+	}
+	return s
+}
+
+// Providers returns the definitions as providers using the HTTP client.
+func Providers(c *http.Client, defs ...Definition) []provider.Provider {
+	p := make([]provider.Provider, 0, len(defs))
+	for _, d := range defs {
+		p = append(p, d.Service(c))
+	}
+	return p
+}
+
+// request the URL of the address family and return a valid IP address of that family.
+func (d Definition) request(ctx context.Context, cancel context.CancelFunc, c *http.Client, f provider.Family,
+) (string, error) {
+	defer cancel()
+	link := d.IPv4
+	if f == provider.IPv6 {
+		link = d.IPv6
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", fault.New(d.Name, err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", fault.New(d.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fault.Wrap(d.Name, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fault.Wrap(d.Name, fault.Parse, err)
+	}
+	ip, err := Parse(b, d.Parser, d.Field)
+	if err != nil {
+		return "", fault.Wrap(d.Name, fault.Parse, err)
+	}
+	if err := Valid(ip, f); err != nil {
+		return ip, fault.Wrap(d.Name, fault.Validation, err)
+	}
+	return ip, nil
+}
+
+// Parse returns the IP address in the body using the parser and field.
+// An empty field uses "ip" for the json and kv parsers.
+func Parse(body []byte, parser, field string) (string, error) {
+	if field == "" && (parser == JSON || parser == KV) {
+		field = "ip"
+	}
+	switch parser {
+	case "", Text:
+		return strings.TrimSpace(string(body)), nil
+	case JSON:
+		return jsonPath(body, field)
+	case KV:
+		return keyValue(body, field)
+	case Regex:
+		re, err := regexp.Compile(field)
+		if err != nil {
+			return "", err
+		}
+		m := re.FindSubmatch(body)
+		switch {
+		case m == nil:
+			return "", fmt.Errorf("%q: %w", field, ErrField)
+		case len(m) > 1:
+			return strings.TrimSpace(string(m[1])), nil
+		}
+		return strings.TrimSpace(string(m[0])), nil
+	}
+	return "", fmt.Errorf("%q: %w", parser, ErrParser)
+}
+
+// jsonPath returns the string value at the dot separated path of object keys
+// and array indexes, for example "data.addresses.0".
+func jsonPath(body []byte, path string) (string, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", err
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch x := v.(type) {
+		case map[string]any:
+			val, ok := x[key]
+			if !ok {
+				return "", fmt.Errorf("%q: %w", path, ErrField)
+			}
+			v = val
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(x) {
+				return "", fmt.Errorf("%q: %w", path, ErrField)
+			}
+			v = x[i]
+		default:
+			return "", fmt.Errorf("%q: %w", path, ErrField)
+		}
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%q: %w", path, ErrField)
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// keyValue returns the value of the key in the lines of key=value or key: value pairs.
+func keyValue(body []byte, key string) (string, error) {
+	for _, line := range strings.Split(string(body), "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			k, v, ok = strings.Cut(line, ":")
+		}
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v), nil
+		}
+	}
+	return "", fmt.Errorf("%q: %w", key, ErrField)
+}
+
+// Valid returns nil if ip is a valid textual representation of an IP address of the family.
+func Valid(ip string, f provider.Family) error {
+	if ip == "" {
+		return ErrNoIP
+	}
+	pip := net.ParseIP(ip)
+	switch {
+	case pip == nil:
+		return ErrInvalid
+	case f == provider.IPv4 && pip.To4() == nil:
+		return ErrNoIPv4
+	case f == provider.IPv6 && pip.To4() != nil:
+		return ErrNoIPv6
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package custom_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/custom"
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		parser string
+		field  string
+		want   string
+		err    error
+	}{
+		{"text", " 192.0.2.1\n", "", "", myiptest.IPv4, nil},
+		{"json", `{"success": true, "ip": "192.0.2.1"}`, custom.JSON, "", myiptest.IPv4, nil},
+		{"json path", `{"data": {"addresses": ["192.0.2.1"]}}`, custom.JSON, "data.addresses.0", myiptest.IPv4, nil},
+		{"json missing", `{"data": {}}`, custom.JSON, "data.ip", "", custom.ErrField},
+		{"json number", `{"ip": 1}`, custom.JSON, "ip", "", custom.ErrField},
+		{"kv", "fl=1\nip=192.0.2.1\nts=1", custom.KV, "", myiptest.IPv4, nil},
+		{"kv colon", "Address: 2001:db8::1\n", custom.KV, "Address", myiptest.IPv6, nil},
+		{"kv missing", "fl=1", custom.KV, "ip", "", custom.ErrField},
+		{"regex", "<p>Current IP Address: 192.0.2.1</p>", custom.Regex, `Address: ([\d.]+)`, myiptest.IPv4, nil},
+		{"regex match", "ip 192.0.2.1", custom.Regex, `[\d.]+\.\d+`, myiptest.IPv4, nil},
+		{"regex missing", "none", custom.Regex, `\d+`, "", custom.ErrField},
+		{"unknown", "", "xml", "", "", custom.ErrParser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := custom.Parse([]byte(tt.body), tt.parser, tt.field)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	const link = "https://ip.example.com"
+	tests := []struct {
+		name string
+		def  custom.Definition
+		want error
+	}{
+		{"valid", custom.Definition{Name: "example", IPv4: link}, nil},
+		{"regex", custom.Definition{Name: "example", IPv6: link, Parser: custom.Regex, Field: `(.+)`}, nil},
+		{"no name", custom.Definition{IPv4: link}, custom.ErrName},
+		{"no url", custom.Definition{Name: "example"}, custom.ErrURL},
+		{"bad url", custom.Definition{Name: "example", IPv4: "ip.example.com"}, custom.ErrURL},
+		{"parser", custom.Definition{Name: "example", IPv4: link, Parser: "xml"}, custom.ErrParser},
+		{"no pattern", custom.Definition{Name: "example", IPv4: link, Parser: custom.Regex}, custom.ErrPattern},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.def.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(s string) string {
+		name := filepath.Join(dir, "providers.json")
+		if err := os.WriteFile(name, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	defs, err := custom.Load(write(`[{"name": "office", "ipv4": "http://10.0.0.1/ip", "parser": "json"}]`))
+	if err != nil || len(defs) != 1 || defs[0].Name != "office" {
+		t.Errorf("Load() = %v, %v, want the office provider", defs, err)
+	}
+	_, err = custom.Load(write(`[{"name": "a", "ipv4": "http://a"}, {"name": "a", "ipv4": "http://b"}]`))
+	if !errors.Is(err, custom.ErrDuplicate) {
+		t.Errorf("Load() error = %v, want %v", err, custom.ErrDuplicate)
+	}
+	if _, err = custom.Load(write(`[{"name": "a", "url": "http://a"}]`)); err == nil {
+		t.Error("Load() error = nil, want an unknown field error")
+	}
+}
+
+func TestDefinition_Service(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"client": {"ip": %q}}`, myiptest.IPv4)
+	})
+	mux.HandleFunc("/v6", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, myiptest.IPv6)
+	})
+	mux.HandleFunc("/wrong", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, myiptest.IPv6)
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	tests := []struct {
+		name  string
+		def   custom.Definition
+		f     provider.Family
+		want  string
+		phase fault.Phase
+	}{
+		{"json", custom.Definition{Name: "a", IPv4: srv.URL + "/json", Parser: custom.JSON, Field: "client.ip"},
+			provider.IPv4, myiptest.IPv4, 0},
+		{"ipv6", custom.Definition{Name: "b", IPv6: srv.URL + "/v6"}, provider.IPv6, myiptest.IPv6, 0},
+		{"wrong family", custom.Definition{Name: "c", IPv4: srv.URL + "/wrong"}, provider.IPv4, "", fault.Validation},
+		{"status", custom.Definition{Name: "d", IPv4: srv.URL + "/status"}, provider.IPv4, "", fault.Status},
+		{"parse", custom.Definition{Name: "e", IPv4: srv.URL + "/v6", Parser: custom.JSON}, provider.IPv4, "", fault.Parse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.def.Service(srv.Client())
+			if s.Name() != tt.def.Name || s.Endpoint(tt.f) == "" {
+				t.Errorf("Service() = %s %q, want %s", s.Name(), s.Endpoint(tt.f), tt.def.Name)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			ip, err := s.Request(ctx, cancel, tt.f)
+			if tt.want != "" {
+				if err != nil || ip != tt.want {
+					t.Errorf("Request() = %q, %v, want %q", ip, err, tt.want)
+				}
+				return
+			}
+			if !fault.Is(err, tt.phase) {
+				t.Errorf("Request() error = %v, want the %s phase", err, tt.phase)
+			}
+		})
+	}
+}
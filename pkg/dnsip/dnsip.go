@@ -0,0 +1,342 @@
+// Package dnsip returns your Internet-facing IPv4 or IPv6 address,
+// sourced from the DNS servers that echo the address of the client,
+// for use on networks that block the HTTP providers but not DNS.
+// It uses a minimal built-in DNS client that queries over UDP
+// and retries over TCP when the response is truncated.
+// © Ben Garrett https://github.com/bengarrett/myip
+package dnsip
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+// dig +short myip.opendns.com @resolver1.opendns.com
+//
+// Output:
+// 1.1.1.1
+
+var (
+	ErrNoIP    = errors.New("ip address is empty")
+	ErrNoIPv4  = errors.New("ip address is not ipv4")
+	ErrNoIPv6  = errors.New("ip address is not ipv6")
+	ErrInvalid = errors.New("ip address is invalid")
+	ErrFamily  = errors.New("service does not support the address family")
+	ErrLink    = errors.New("dns link is invalid, it must use the dns://server/name?type=A form")
+	ErrRCode   = errors.New("unusual dns server response")
+)
+
+// The DNS echo services.
+const (
+	OpenDNSv4    = "dns://208.67.222.222/myip.opendns.com?type=A"                     // OpenDNSv4 is the OpenDNS IPv4 query.
+	OpenDNSv6    = "dns://[2620:119:35::35]/myip.opendns.com?type=AAAA"               // OpenDNSv6 is the OpenDNS IPv6 query.
+	Googlev4     = "dns://216.239.32.10/o-o.myaddr.l.google.com?type=TXT"             // Googlev4 is the Google IPv4 query.
+	Googlev6     = "dns://[2001:4860:4802:32::a]/o-o.myaddr.l.google.com?type=TXT"    // Googlev6 is the Google IPv6 query.
+	Cloudflarev4 = "dns://1.1.1.1/whoami.cloudflare?class=CH&type=TXT"                // Cloudflarev4 is the Cloudflare IPv4 query.
+	Cloudflarev6 = "dns://[2606:4700:4700::1111]/whoami.cloudflare?class=CH&type=TXT" // Cloudflarev6 is the Cloudflare IPv6 query.
+)
+
+const port = "53"
+
+// Client queries a DNS echo service using a dialer and query links.
+// A link is a DNS URI of the server and question, for example
+// dns://208.67.222.222/myip.opendns.com?type=A or
+// dns://1.1.1.1/whoami.cloudflare?class=CH&type=TXT.
+type Client struct {
+	Dialer *net.Dialer // Dialer for the queries, a nil value uses the zero dialer.
+	Domain string      // Domain of the service that is used in the errors.
+	Linkv4 string      // Linkv4 is the IPv4 query link, an empty value is unsupported.
+	Linkv6 string      // Linkv6 is the IPv6 query link, an empty value is unsupported.
+}
+
+func (c Client) dialer() *net.Dialer {
+	if c.Dialer == nil {
+		return &net.Dialer{}
+	}
+	return c.Dialer
+}
+
+// URLs returns the IPv4 and IPv6 query links used by the client.
+func (c Client) URLs() (string, string) {
+	return c.Linkv4, c.Linkv6
+}
+
+// OpenDNS returns a client of the OpenDNS resolvers, which answer the
+// myip.opendns.com A and AAAA questions with the address of the client.
+func OpenDNS() Client {
+	return Client{Domain: "opendns.com", Linkv4: OpenDNSv4, Linkv6: OpenDNSv6}
+}
+
+// Google returns a client of the Google name servers, which answer the
+// o-o.myaddr.l.google.com TXT question with the address of the client.
+func Google() Client {
+	return Client{Domain: "google.com", Linkv4: Googlev4, Linkv6: Googlev6}
+}
+
+// Cloudflare returns a client of the Cloudflare resolvers, which answer the
+// whoami.cloudflare CHAOS TXT question with the address of the client.
+func Cloudflare() Client {
+	return Client{Domain: "cloudflare.com", Linkv4: Cloudflarev4, Linkv6: Cloudflarev6}
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return c.family(ctx, cancel, c.Linkv4, false)
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return c.family(ctx, cancel, c.Linkv6, true)
+}
+
+func (c Client) family(ctx context.Context, cancel context.CancelFunc, link string, ipv6 bool) (string, error) {
+	if link == "" {
+		defer cancel()
+		return "", fault.New(c.Domain, ErrFamily)
+	}
+	ip, err := c.Request(ctx, cancel, link, ipv6)
+	if err != nil {
+		return "", err
+	}
+	if err := Valid(ipv6, ip); err != nil {
+		return ip, fault.Wrap(c.Domain, fault.Validation, err)
+	}
+	return ip, nil
+}
+
+// Request queries the server of the link over the address family
+// and returns the IP address in the first answer.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, link string, ipv6 bool) (string, error) {
+	defer cancel()
+
+	server, q, err := Parse(link)
+	if err != nil {
+		return "", fault.Wrap(c.Domain, fault.Request, err)
+	}
+	network := "4"
+	if ipv6 {
+		network = "6"
+	}
+	m, err := c.Exchange(ctx, network, server, q)
+	if errors.Is(err, ErrMessage) {
+		return "", fault.Wrap(c.Domain, fault.Parse, err)
+	}
+	if err != nil {
+		return "", fault.New(c.Domain, err)
+	}
+	if rc := m.RCode(); rc != 0 {
+		return "", fault.Wrap(c.Domain, fault.Status, fmt.Errorf("%s, %w", rcode(rc), ErrRCode))
+	}
+	ip, err := Answer(m)
+	if err != nil {
+		return "", fault.Wrap(c.Domain, fault.Parse, err)
+	}
+	return ip, nil
+}
+
+// Exchange sends the question to the server over UDP and returns the response,
+// which is requested again over TCP when it is truncated.
+// The network is either 4 or 6 to force the address family, or empty for either.
+func (c Client) Exchange(ctx context.Context, network, server string, q Question) (Message, error) {
+	//nolint: gosec // the id only matches a response to the query, it is not a secret
+	query := Message{ID: uint16(rand.Uint32()), Flags: FlagRD, Questions: []Question{q}}
+	b, err := query.Pack()
+	if err != nil {
+		return Message{}, err
+	}
+	m, err := c.udp(ctx, "udp"+network, server, query.ID, b)
+	if err != nil || m.Flags&FlagTC == 0 {
+		return m, err
+	}
+	return c.tcp(ctx, "tcp"+network, server, query.ID, b)
+}
+
+func (c Client) udp(ctx context.Context, network, server string, id uint16, query []byte) (Message, error) {
+	conn, err := c.dialer().DialContext(ctx, network, server)
+	if err != nil {
+		return Message{}, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return Message{}, ctxErr(ctx, err)
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return Message{}, ctxErr(ctx, err)
+		}
+		// ignore any stray, malformed or spoofed datagrams
+		m, err := Unpack(buf[:n])
+		if err != nil {
+			continue
+		}
+		if m.ID == id && m.Flags&FlagQR != 0 {
+			return m, nil
+		}
+	}
+}
+
+func (c Client) tcp(ctx context.Context, network, server string, id uint16, query []byte) (Message, error) {
+	conn, err := c.dialer().DialContext(ctx, network, server)
+	if err != nil {
+		return Message{}, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	// tcp messages are prefixed with a two byte length
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(b, query...)); err != nil {
+		return Message{}, ctxErr(ctx, err)
+	}
+	size := make([]byte, 2)
+	if _, err := io.ReadFull(conn, size); err != nil {
+		return Message{}, ctxErr(ctx, err)
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return Message{}, ctxErr(ctx, err)
+	}
+	m, err := Unpack(buf)
+	if err != nil {
+		return Message{}, err
+	}
+	if m.ID != id {
+		return Message{}, fmt.Errorf("%w: response id %d does not match the query", ErrMessage, m.ID)
+	}
+	return m, nil
+}
+
+// ctxErr returns the context error when the context is done,
+// as closing the connection hides the cause of the failed read or write.
+func ctxErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// Answer returns the IP address of the first A, AAAA or TXT answer of the response.
+// Answers of a different type to the question, such as a CNAME, are skipped.
+func Answer(m Message) (string, error) {
+	for _, r := range m.Answers {
+		if len(m.Questions) > 0 && r.Type != m.Questions[0].Type {
+			continue
+		}
+		switch r.Type {
+		case TypeA:
+			if len(r.Data) != net.IPv4len {
+				return "", fmt.Errorf("%w: a address length %d", ErrMessage, len(r.Data))
+			}
+			return net.IP(r.Data).String(), nil
+		case TypeAAAA:
+			if len(r.Data) != net.IPv6len {
+				return "", fmt.Errorf("%w: aaaa address length %d", ErrMessage, len(r.Data))
+			}
+			return net.IP(r.Data).String(), nil
+		case TypeTXT:
+			s, err := r.Text()
+			if err != nil {
+				return "", err
+			}
+			// google may also answer with a "edns0-client-subnet" string
+			if net.ParseIP(s) != nil {
+				return s, nil
+			}
+		}
+	}
+	return "", ErrNoIP
+}
+
+// Parse returns the server address and question of a DNS URI link.
+// The server port defaults to 53, the type to A and the class to IN.
+func Parse(link string) (string, Question, error) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "dns" || u.Hostname() == "" {
+		return "", Question{}, fmt.Errorf("%q: %w", link, ErrLink)
+	}
+	server := u.Host
+	if u.Port() == "" {
+		server = net.JoinHostPort(u.Hostname(), port)
+	}
+	q := Question{Name: strings.Trim(u.Path, "/"), Type: TypeA, Class: ClassINET}
+	if q.Name == "" {
+		return "", Question{}, fmt.Errorf("%q: %w", link, ErrLink)
+	}
+	// rfc 4501 separates the parameters using semicolons
+	vals, err := url.ParseQuery(strings.ReplaceAll(u.RawQuery, ";", "&"))
+	if err != nil {
+		return "", Question{}, fmt.Errorf("%q: %w", link, ErrLink)
+	}
+	if s := vals.Get("type"); s != "" {
+		if q.Type = types[strings.ToUpper(s)]; q.Type == 0 {
+			return "", Question{}, fmt.Errorf("%q: type %s: %w", link, s, ErrLink)
+		}
+	}
+	if s := vals.Get("class"); s != "" {
+		if q.Class = classes[strings.ToUpper(s)]; q.Class == 0 {
+			return "", Question{}, fmt.Errorf("%q: class %s: %w", link, s, ErrLink)
+		}
+	}
+	return server, q, nil
+}
+
+var (
+	types   = map[string]uint16{"A": TypeA, "AAAA": TypeAAAA, "TXT": TypeTXT}           //nolint: gochecknoglobals
+	classes = map[string]uint16{"IN": ClassINET, "CH": ClassCHAOS, "CHAOS": ClassCHAOS} //nolint: gochecknoglobals
+)
+
+func rcode(rc uint16) string {
+	switch rc {
+	case 1:
+		return "format error"
+	case 2:
+		return "server failure"
+	case 3:
+		return "name error"
+	case 4:
+		return "not implemented"
+	case 5:
+		return "refused"
+	}
+	return "rcode " + strconv.Itoa(int(rc))
+}
+
+// Valid returns nil if s is a valid textual representation of an IP address of the family.
+func Valid(ipv6 bool, s string) error {
+	if s == "" {
+		return ErrNoIP
+	}
+
+	ip := net.ParseIP(s)
+	switch {
+	case ip == nil:
+		return ErrInvalid
+	case ipv6 && ip.To4() != nil:
+		return ErrNoIPv6
+	case !ipv6 && ip.To4() == nil:
+		return ErrNoIPv4
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package dnsip_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/dnsip"
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/myiptest"
+)
+
+// ExampleOpenDNS demonstrates an IPv4 address query with a 5 second timeout.
+func ExampleOpenDNS() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := dnsip.OpenDNS().IPv4(ctx, cancel)
+	if err != nil {
+		log.Printf("\n%s\n", err)
+	}
+	fmt.Println(s)
+}
+
+func TestTimeout(t *testing.T) {
+	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
+	if _, err := dnsip.Cloudflare().IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		link   string
+		server string
+		q      dnsip.Question
+		err    error
+	}{
+		{"opendns", dnsip.OpenDNSv6, "[2620:119:35::35]:53",
+			dnsip.Question{Name: "myip.opendns.com", Type: dnsip.TypeAAAA, Class: dnsip.ClassINET}, nil},
+		{"cloudflare", dnsip.Cloudflarev4, "1.1.1.1:53",
+			dnsip.Question{Name: "whoami.cloudflare", Type: dnsip.TypeTXT, Class: dnsip.ClassCHAOS}, nil},
+		{"defaults", "dns://127.0.0.1:5353/example.com", "127.0.0.1:5353",
+			dnsip.Question{Name: "example.com", Type: dnsip.TypeA, Class: dnsip.ClassINET}, nil},
+		{"semicolon", "dns://1.1.1.1/whoami.cloudflare?class=ch;type=txt", "1.1.1.1:53",
+			dnsip.Question{Name: "whoami.cloudflare", Type: dnsip.TypeTXT, Class: dnsip.ClassCHAOS}, nil},
+		{"scheme", "https://1.1.1.1/example.com", "", dnsip.Question{}, dnsip.ErrLink},
+		{"no name", "dns://1.1.1.1/", "", dnsip.Question{}, dnsip.ErrLink},
+		{"type", "dns://1.1.1.1/example.com?type=MX", "", dnsip.Question{}, dnsip.ErrLink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, q, err := dnsip.Parse(tt.link)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.err)
+			}
+			if server != tt.server || q != tt.q {
+				t.Errorf("Parse() = %q %+v, want %q %+v", server, q, tt.server, tt.q)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		truncate bool
+		class    string
+		typ      string
+		want     string
+		phase    fault.Phase
+	}{
+		{"a", myiptest.OK, false, "IN", "A", myiptest.IPv4, 0},
+		{"txt", myiptest.OK, false, "IN", "TXT", myiptest.IPv4, 0},
+		{"chaos", myiptest.OK, false, "CH", "TXT", myiptest.IPv4, 0},
+		{"tcp", myiptest.OK, true, "IN", "A", myiptest.IPv4, 0},
+		{"wrong family", myiptest.WrongFamily, false, "IN", "A", "", fault.Parse},
+		{"servfail", myiptest.Status, false, "IN", "A", "", fault.Status},
+		{"malformed", myiptest.Malformed, false, "IN", "A", "", fault.Timeout},
+		{"slow", myiptest.Slow, false, "IN", "A", "", fault.Timeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewDNSServer(tt.behavior, tt.truncate)
+			defer srv.Close()
+			c := dnsip.Client{Domain: "example.com", Linkv4: srv.Link("myip.example.com", tt.class, tt.typ)}
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			s, err := c.IPv4(ctx, cancel)
+			if tt.want != "" {
+				if err != nil || s != tt.want {
+					t.Errorf("IPv4() = %q, %v, want %q", s, err, tt.want)
+				}
+				return
+			}
+			if !fault.Is(err, tt.phase) {
+				t.Errorf("IPv4() error = %v, want the %s phase", err, tt.phase)
+			}
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if _, err := (dnsip.Client{Linkv4: dnsip.OpenDNSv4}).IPv6(ctx, cancel); !errors.Is(err, dnsip.ErrFamily) {
+		t.Errorf("IPv6() error = %v, want %v", err, dnsip.ErrFamily)
+	}
+}
+
+func TestClient_Exchange(t *testing.T) {
+	srv := myiptest.NewDNSServer(myiptest.OK, false)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q := dnsip.Question{Name: "myip.opendns.com", Type: dnsip.TypeAAAA, Class: dnsip.ClassINET}
+	m, err := dnsip.Client{}.Exchange(ctx, "", srv.Addr, q)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if ip, err := dnsip.Answer(m); err != nil || ip != myiptest.IPv6 {
+		t.Errorf("Answer() = %q, %v, want %q", ip, err, myiptest.IPv6)
+	}
+	if _, err := dnsip.Answer(dnsip.Message{}); !errors.Is(err, dnsip.ErrNoIP) {
+		t.Errorf("Answer() error = %v, want %v", err, dnsip.ErrNoIP)
+	}
+}
+
+func TestClient_Exchange_stray(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 512)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		q, err := dnsip.Unpack(buf[:n])
+		if err != nil {
+			return
+		}
+		reply := func(id uint16) []byte {
+			m := dnsip.Message{ID: id, Flags: dnsip.FlagQR, Questions: q.Questions, Answers: []dnsip.Record{
+				{Name: q.Questions[0].Name, Type: dnsip.TypeA, Class: dnsip.ClassINET, TTL: 60, Data: []byte{192, 0, 2, 1}},
+			}}
+			b, _ := m.Pack()
+			return b
+		}
+		// a malformed and a mismatched datagram are sent before the response
+		_, _ = conn.WriteTo([]byte("malformed"), addr)
+		_, _ = conn.WriteTo(reply(q.ID+1), addr)
+		_, _ = conn.WriteTo(reply(q.ID), addr)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q := dnsip.Question{Name: "myip.example.com", Type: dnsip.TypeA, Class: dnsip.ClassINET}
+	m, err := dnsip.Client{}.Exchange(ctx, "4", conn.LocalAddr().String(), q)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if ip, err := dnsip.Answer(m); err != nil || ip != myiptest.IPv4 {
+		t.Errorf("Answer() = %q, %v, want %q", ip, err, myiptest.IPv4)
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	a := dnsip.Record{Type: dnsip.TypeA, Class: dnsip.ClassINET, Data: []byte{192, 0, 2, 1}}
+	cname := dnsip.Record{Type: 5, Class: dnsip.ClassINET, Data: []byte{0}}
+	aaaa := dnsip.Record{Type: dnsip.TypeAAAA, Class: dnsip.ClassINET, Data: net.ParseIP(myiptest.IPv6)}
+	question := func(typ uint16) []dnsip.Question {
+		return []dnsip.Question{{Name: "myip.example.com", Type: typ, Class: dnsip.ClassINET}}
+	}
+	tests := []struct {
+		name string
+		m    dnsip.Message
+		want string
+		err  error
+	}{
+		{"a", dnsip.Message{Questions: question(dnsip.TypeA), Answers: []dnsip.Record{a}}, myiptest.IPv4, nil},
+		{"cname", dnsip.Message{Questions: question(dnsip.TypeA), Answers: []dnsip.Record{cname, a}}, myiptest.IPv4, nil},
+		{"aaaa", dnsip.Message{Questions: question(dnsip.TypeAAAA), Answers: []dnsip.Record{aaaa}}, myiptest.IPv6, nil},
+		{"mismatch", dnsip.Message{Questions: question(dnsip.TypeA), Answers: []dnsip.Record{aaaa}}, "", dnsip.ErrNoIP},
+		{"length", dnsip.Message{Questions: question(dnsip.TypeAAAA), Answers: []dnsip.Record{
+			{Type: dnsip.TypeAAAA, Data: []byte{192, 0, 2, 1}},
+		}}, "", dnsip.ErrMessage},
+		{"empty", dnsip.Message{}, "", dnsip.ErrNoIP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := dnsip.Answer(tt.m)
+			if !errors.Is(err, tt.err) || ip != tt.want {
+				t.Errorf("Answer() = %q, %v, want %q, %v", ip, err, tt.want, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package dnsip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMessage = errors.New("dns message is malformed")
+	ErrName    = errors.New("dns name is invalid")
+)
+
+// Resource record types.
+const (
+	TypeA    uint16 = 1  // TypeA is an IPv4 host address.
+	TypeTXT  uint16 = 16 // TypeTXT is a text string.
+	TypeAAAA uint16 = 28 // TypeAAAA is an IPv6 host address.
+)
+
+// Resource record classes.
+const (
+	ClassINET  uint16 = 1 // ClassINET is the Internet class.
+	ClassCHAOS uint16 = 3 // ClassCHAOS is the Chaos class, used by server information queries.
+)
+
+// Header flags.
+const (
+	FlagQR uint16 = 1 << 15 // FlagQR marks a response.
+	FlagTC uint16 = 1 << 9  // FlagTC marks a truncated response.
+	FlagRD uint16 = 1 << 8  // FlagRD asks the server to recursively resolve the question.
+)
+
+const (
+	headerLen  = 12
+	maxLabel   = 63
+	maxName    = 255
+	maxPointer = 16
+)
+
+// Question is the name, type and class of a query.
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// Record is a resource record of a response.
+type Record struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte // Data is the raw record data.
+}
+
+// Text returns the joined character strings of a TXT record.
+func (r Record) Text() (string, error) {
+	var sb strings.Builder
+	b := r.Data
+	for len(b) > 0 {
+		n := int(b[0])
+		if len(b) < n+1 {
+			return "", ErrMessage
+		}
+		sb.Write(b[1 : n+1])
+		b = b[n+1:]
+	}
+	return sb.String(), nil
+}
+
+// Message is a DNS query or response, the authority and additional sections are ignored.
+type Message struct {
+	ID        uint16
+	Flags     uint16
+	Questions []Question
+	Answers   []Record
+}
+
+// RCode returns the response code of the message.
+func (m Message) RCode() uint16 {
+	return m.Flags & 0xf
+}
+
+// Pack returns the message in the DNS wire format.
+func (m Message) Pack() ([]byte, error) {
+	b := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	binary.BigEndian.PutUint16(b[2:], m.Flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Answers)))
+	var err error
+	for _, q := range m.Questions {
+		if b, err = packName(b, q.Name); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, q.Type)
+		b = binary.BigEndian.AppendUint16(b, q.Class)
+	}
+	for _, r := range m.Answers {
+		if b, err = packName(b, r.Name); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, r.Type)
+		b = binary.BigEndian.AppendUint16(b, r.Class)
+		b = binary.BigEndian.AppendUint32(b, r.TTL)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(r.Data)))
+		b = append(b, r.Data...)
+	}
+	return b, nil
+}
+
+// Unpack parses a message in the DNS wire format.
+func Unpack(b []byte) (Message, error) {
+	if len(b) < headerLen {
+		return Message{}, fmt.Errorf("%w: short header", ErrMessage)
+	}
+	m := Message{
+		ID:    binary.BigEndian.Uint16(b[0:]),
+		Flags: binary.BigEndian.Uint16(b[2:]),
+	}
+	qd, an := int(binary.BigEndian.Uint16(b[4:])), int(binary.BigEndian.Uint16(b[6:]))
+	off := headerLen
+	for i := 0; i < qd; i++ {
+		name, n, err := unpackName(b, off)
+		if err != nil {
+			return Message{}, err
+		}
+		off = n
+		if len(b) < off+4 {
+			return Message{}, fmt.Errorf("%w: short question", ErrMessage)
+		}
+		m.Questions = append(m.Questions, Question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(b[off:]),
+			Class: binary.BigEndian.Uint16(b[off+2:]),
+		})
+		off += 4
+	}
+	for i := 0; i < an; i++ {
+		name, n, err := unpackName(b, off)
+		if err != nil {
+			return Message{}, err
+		}
+		off = n
+		if len(b) < off+10 {
+			return Message{}, fmt.Errorf("%w: short record", ErrMessage)
+		}
+		r := Record{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(b[off:]),
+			Class: binary.BigEndian.Uint16(b[off+2:]),
+			TTL:   binary.BigEndian.Uint32(b[off+4:]),
+		}
+		size := int(binary.BigEndian.Uint16(b[off+8:]))
+		off += 10
+		if len(b) < off+size {
+			return Message{}, fmt.Errorf("%w: short record data", ErrMessage)
+		}
+		r.Data = b[off : off+size]
+		off += size
+		m.Answers = append(m.Answers, r)
+	}
+	return m, nil
+}
+
+// packName appends the name as a sequence of length prefixed labels.
+func packName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > maxName-2 {
+		return nil, fmt.Errorf("%w: %q is too long", ErrName, name)
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > maxLabel {
+				return nil, fmt.Errorf("%w: %q", ErrName, name)
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0), nil
+}
+
+// unpackName returns the name at the offset, following any compression pointers,
+// and the offset of the data that follows the name.
+func unpackName(b []byte, off int) (string, int, error) {
+	labels, next, jumps := []string{}, -1, 0
+	for {
+		if off >= len(b) {
+			return "", 0, fmt.Errorf("%w: short name", ErrMessage)
+		}
+		n := int(b[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, "."), next, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(b) || jumps >= maxPointer {
+				return "", 0, fmt.Errorf("%w: bad name pointer", ErrMessage)
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3fff)
+			jumps++
+		case n > maxLabel:
+			return "", 0, fmt.Errorf("%w: bad label length", ErrMessage)
+		default:
+			if off+1+n > len(b) {
+				return "", 0, fmt.Errorf("%w: short label", ErrMessage)
+			}
+			labels = append(labels, string(b[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package dnsip_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/dnsip"
+)
+
+func TestMessage_Pack(t *testing.T) {
+	q := dnsip.Question{Name: "whoami.cloudflare.", Type: dnsip.TypeTXT, Class: dnsip.ClassCHAOS}
+	m := dnsip.Message{ID: 0xbeef, Flags: dnsip.FlagRD, Questions: []dnsip.Question{q}}
+	b, err := m.Pack()
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	want := []byte{
+		0xbe, 0xef, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0,
+		6, 'w', 'h', 'o', 'a', 'm', 'i', 10, 'c', 'l', 'o', 'u', 'd', 'f', 'l', 'a', 'r', 'e', 0,
+		0, 16, 0, 3,
+	}
+	if !bytes.Equal(b, want) {
+		t.Errorf("Pack() = %v, want %v", b, want)
+	}
+	got, err := dnsip.Unpack(b)
+	if err != nil || got.ID != m.ID || len(got.Questions) != 1 || got.Questions[0].Name != "whoami.cloudflare" {
+		t.Errorf("Unpack() = %+v, %v, want the packed message", got, err)
+	}
+	long := dnsip.Message{Questions: []dnsip.Question{{Name: string(bytes.Repeat([]byte("a"), 64)) + ".com"}}}
+	if _, err := long.Pack(); !errors.Is(err, dnsip.ErrName) {
+		t.Errorf("Pack() error = %v, want %v", err, dnsip.ErrName)
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	header := []byte{0, 1, 0x81, 0x80, 0, 1, 0, 1, 0, 0, 0, 0}
+	question := []byte{4, 'm', 'y', 'i', 'p', 0, 0, 1, 0, 1}
+	// the answer name is a compression pointer to the question name at offset 12,
+	// while the loop name points to itself at offset 22
+	answer := []byte{0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1}
+	loop := []byte{0xc0, 22, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1}
+	join := func(b ...[]byte) []byte { return bytes.Join(b, nil) }
+	tests := []struct {
+		name string
+		b    []byte
+		want string
+		err  error
+	}{
+		{"pointer", join(header, question, answer), "myip", nil},
+		{"loop", join(header, question, loop), "", dnsip.ErrMessage},
+		{"short header", header[:6], "", dnsip.ErrMessage},
+		{"short answer", join(header, question, answer[:8]), "", dnsip.ErrMessage},
+		{"short data", join(header, question, answer[:14]), "", dnsip.ErrMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := dnsip.Unpack(tt.b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Unpack() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(m.Answers) != 1 || m.Answers[0].Name != tt.want || m.Answers[0].TTL != 60 {
+				t.Errorf("Unpack() answers = %+v, want the %s record", m.Answers, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecord_Text(t *testing.T) {
+	r := dnsip.Record{Type: dnsip.TypeTXT, Data: []byte("\x04192.\x050.2.1\x00")}
+	if s, err := r.Text(); err != nil || s != "192.0.2.1" {
+		t.Errorf("Text() = %q, %v, want %q", s, err, "192.0.2.1")
+	}
+	r.Data = []byte("\x09192.0")
+	if _, err := r.Text(); !errors.Is(err, dnsip.ErrMessage) {
+		t.Errorf("Text() error = %v, want %v", err, dnsip.ErrMessage)
+	}
+}
@@ -0,0 +1,125 @@
+// Package fault defines the error returned by the provider requests,
+// which names the provider and the phase of the request that failed.
+// © Ben Garrett https://github.com/bengarrett/myip
+package fault
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Phase is the stage of a provider request.
+type Phase uint8
+
+const (
+	Request    Phase = iota // Request is the creation or sending of the request.
+	Dial                    // Dial is the DNS lookup and connection to the server.
+	TLS                     // TLS is the secure handshake and certificate verification.
+	Status                  // Status is an unusual HTTP status code response.
+	Parse                   // Parse is the reading and decoding of the response body.
+	Validation              // Validation is the check of the returned IP address.
+	Timeout                 // Timeout is a request that exceeded its deadline.
+	Canceled                // Canceled is a request that was aborted by the caller.
+)
+
+func (p Phase) String() string {
+	switch p {
+	case Request:
+		return "request"
+	case Dial:
+		return "dial"
+	case TLS:
+		return "tls"
+	case Status:
+		return "status"
+	case Parse:
+		return "parse"
+	case Validation:
+		return "validation"
+	case Timeout:
+		return "timeout"
+	case Canceled:
+		return "canceled"
+	}
+	return fmt.Sprintf("phase(%d)", uint8(p))
+}
+
+// Error is a failed provider request.
+type Error struct {
+	Provider string // Provider is the domain name of the provider.
+	Phase    Phase  // Phase is the stage of the request that failed.
+	Err      error  // Err is the cause of the failure.
+}
+
+func (e *Error) Error() string {
+	if e.Phase == Timeout || e.Phase == Canceled {
+		return fmt.Sprintf("%s: %s", e.Provider, e.Phase)
+	}
+	return fmt.Sprintf("%s %s error: %s", e.Provider, e.Phase, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns err as a provider error of the phase.
+// A nil err returns nil.
+func Wrap(provider string, p Phase, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Provider: provider, Phase: p, Err: err}
+}
+
+// New returns err as a provider error with a phase determined by its cause.
+// A nil err returns nil and an existing provider error is returned unchanged.
+func New(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Provider: provider, Phase: Classify(err), Err: err}
+}
+
+// Classify returns the request phase that most likely caused the error.
+func Classify(err error) Phase {
+	var (
+		netErr  net.Error
+		dnsErr  *net.DNSError
+		opErr   *net.OpError
+		certErr *tls.CertificateVerificationError
+		authErr x509.UnknownAuthorityError
+		hostErr x509.HostnameError
+		invErr  x509.CertificateInvalidError
+		recErr  tls.RecordHeaderError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return Timeout
+	case errors.As(err, &certErr), errors.As(err, &authErr), errors.As(err, &hostErr),
+		errors.As(err, &invErr), errors.As(err, &recErr):
+		return TLS
+	case errors.As(err, &dnsErr), errors.As(err, &opErr) && opErr.Op == "dial":
+		return Dial
+	}
+	return Request
+}
+
+// Is reports whether err is a provider error of the phase.
+func Is(err error, p Phase) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Phase == p
+	}
+	return false
+}
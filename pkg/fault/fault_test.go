@@ -0,0 +1,64 @@
+package fault_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+var errFake = errors.New("fake error")
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want fault.Phase
+	}{
+		{"request", errFake, fault.Request},
+		{"canceled", fmt.Errorf("get: %w", context.Canceled), fault.Canceled},
+		{"timeout", fmt.Errorf("get: %w", context.DeadlineExceeded), fault.Timeout},
+		{"dns", &net.DNSError{Err: "no such host", Name: "example.com"}, fault.Dial},
+		{"dial", &net.OpError{Op: "dial", Net: "tcp", Err: errFake}, fault.Dial},
+		{"read", &net.OpError{Op: "read", Net: "tcp", Err: errFake}, fault.Request},
+		{"tls", fmt.Errorf("get: %w", x509.UnknownAuthorityError{}), fault.TLS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fault.Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if err := fault.New("example.com", nil); err != nil {
+		t.Errorf("New() = %v, want nil", err)
+	}
+	err := fault.New("example.com", context.DeadlineExceeded)
+	if got, want := err.Error(), "example.com: timeout"; got != want {
+		t.Errorf("New() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("New() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	status := fault.Wrap("example.com", fault.Status, errFake)
+	if got := fault.New("other.com", status); got != status {
+		t.Errorf("New() = %v, want %v", got, status)
+	}
+	if got, want := status.Error(), "example.com status error: fake error"; got != want {
+		t.Errorf("Wrap() = %q, want %q", got, want)
+	}
+	var e *fault.Error
+	if !errors.As(status, &e) || e.Provider != "example.com" || e.Phase != fault.Status {
+		t.Errorf("Wrap() = %#v, want a status fault", e)
+	}
+	if fault.Is(errFake, fault.Request) {
+		t.Errorf("Is() = true, want false")
+	}
+}
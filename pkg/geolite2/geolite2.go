@@ -10,14 +10,48 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"sync/atomic"
 
 	"github.com/oschwald/maxminddb-golang"
 )
 
-var ErrInvalid = errors.New("ip address is an invalid textual representation")
+var (
+	ErrInvalid  = errors.New("ip address is an invalid textual representation")
+	ErrLanguage = errors.New("geolocation language is unsupported")
+)
 
 const lang = "en"
 
+var language atomic.Value //nolint: gochecknoglobals
+
+// Languages returns the locale codes of the location names in the GeoLite2 data.
+func Languages() []string {
+	return []string{"de", "en", "es", "fr", "ja", "pt-BR", "ru", "zh-CN"}
+}
+
+// SetLanguage sets the locale code of the location names returned by Lookup, City and Country.
+// The default language is English, "en".
+func SetLanguage(code string) error {
+	for _, l := range Languages() {
+		if strings.EqualFold(l, code) {
+			language.Store(l)
+			return nil
+		}
+	}
+	return fmt.Errorf("%q: %w", code, ErrLanguage)
+}
+
+// name returns the location name in the set language, or English when it is unavailable.
+func name(names map[string]string) string {
+	if l, ok := language.Load().(string); ok {
+		if s := names[l]; s != "" {
+			return s
+		}
+	}
+	return names[lang]
+}
+
 //go:embed db/GeoLite2-Country/GeoLite2-Country.mmdb
 var country []byte
 
@@ -47,43 +81,70 @@ func Country(ip string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return record.Country.Names[lang], nil
+	return name(record.Country.Names), nil
 }
 
-func City(ip string) (string, error) {
+// Location is the geographic location of an IP address.
+type Location struct {
+	City    string // City is the city name.
+	Country string // Country is the country name.
+	ISOCode string // ISOCode is the two-character ISO 3166-1 country code.
+}
+
+// String returns the city and country names separated by a comma.
+func (l Location) String() string {
+	ct, co := l.City, l.Country
+	switch {
+	case ct != "" && co != "":
+		return fmt.Sprintf("%s, %s", ct, co)
+	case co != "":
+		return co
+	case ct != "":
+		return ct
+	default:
+		return ""
+	}
+}
+
+// Lookup returns the city and country location of the IP address.
+// Reserved IP addresses such as 127.0.0.1 return an empty location.
+func Lookup(ip string) (Location, error) {
 	db, err := maxminddb.FromBytes(city)
 	if err != nil {
-		return "", err
+		return Location{}, err
 	}
 	defer db.Close()
 
 	pip := net.ParseIP(ip)
 	if pip == nil {
-		return "", ErrInvalid
+		return Location{}, ErrInvalid
 	}
 
 	var record struct {
 		Country struct {
-			Names map[string]string `maxminddb:"names"`
+			ISOCode string            `maxminddb:"iso_code"`
+			Names   map[string]string `maxminddb:"names"`
 		} `maxminddb:"country"`
 		City struct {
 			Names map[string]string `maxminddb:"names"`
 		} `maxminddb:"city"`
-	} // Or any appropriate struct
+	}
 
 	err = db.Lookup(pip, &record)
 	if err != nil {
-		return "", err
+		return Location{}, err
 	}
-	ct, co := record.City.Names[lang], record.Country.Names[lang]
-	switch {
-	case ct != "" && co != "":
-		return fmt.Sprintf("%s, %s", ct, co), nil
-	case co != "":
-		return co, nil
-	case ct != "":
-		return ct, nil
-	default:
-		return "", nil
+	return Location{
+		City:    name(record.City.Names),
+		Country: name(record.Country.Names),
+		ISOCode: record.Country.ISOCode,
+	}, nil
+}
+
+func City(ip string) (string, error) {
+	l, err := Lookup(ip)
+	if err != nil {
+		return "", err
 	}
+	return l.String(), nil
 }
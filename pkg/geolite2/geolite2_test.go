@@ -54,6 +54,31 @@ func ExampleCountry() {
 	// Output: United States
 }
 
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      string
+		want    geolite2.Location
+		wantErr bool
+	}{
+		{"empty", "", geolite2.Location{}, true},
+		{"reserved", "0.0.0.0", geolite2.Location{}, false},
+		{"valid", example, geolite2.Location{City: "Norwell", Country: "United States", ISOCode: "US"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := geolite2.Lookup(tt.ip)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLocations(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -89,3 +114,30 @@ func TestLocations(t *testing.T) {
 		})
 	}
 }
+
+func TestSetLanguage(t *testing.T) {
+	defer func() {
+		_ = geolite2.SetLanguage("en")
+	}()
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{"german", "de", "USA", false},
+		{"case", "PT-br", "Estados Unidos", false},
+		{"unsupported", "xx", "Estados Unidos", true},
+		{"english", "en", "United States", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := geolite2.SetLanguage(tt.code); (err != nil) != tt.wantErr {
+				t.Errorf("SetLanguage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got, _ := geolite2.Country(example); got != tt.want {
+				t.Errorf("Country() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package history records the observed Internet-facing IP addresses
+// to a JSON lines file and summarises how long each address was held.
+// © Ben Garrett https://github.com/bengarrett/myip
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/statedir"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+var ErrRecord = errors.New("history record is invalid")
+
+const (
+	dirPerm  = 0o755
+	filePerm = 0o644
+)
+
+// Record is an observed IP address of an address family.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Family    string    `json:"family"`
+	IP        string    `json:"ip"`
+	City      string    `json:"city,omitempty"`
+	Country   string    `json:"country,omitempty"`
+	ISOCode   string    `json:"country_code,omitempty"`
+	Providers []string  `json:"providers,omitempty"`
+}
+
+// NewRecord returns the record of the observation.
+func NewRecord(o watch.Observation) Record {
+	return Record{
+		Time:      o.Time,
+		Family:    o.Family.String(),
+		IP:        o.IP,
+		City:      o.Location.City,
+		Country:   o.Location.Country,
+		ISOCode:   o.Location.ISOCode,
+		Providers: o.Providers,
+	}
+}
+
+// Location returns the geographic location of the record.
+func (r Record) Location() geolite2.Location {
+	return geolite2.Location{City: r.City, Country: r.Country, ISOCode: r.ISOCode}
+}
+
+// File returns the path of the history file in the state directory.
+func File() (string, error) {
+	return statedir.File("history.jsonl")
+}
+
+// Append writes the observations as records to the end of the named file,
+// creating the file and any missing parent directories.
+// Observations without an IP address are ignored.
+func Append(name string, obs ...watch.Observation) error {
+	var b []byte
+	for _, o := range obs {
+		if o.IP == "" {
+			continue
+		}
+		line, err := json.Marshal(NewRecord(o))
+		if err != nil {
+			return err
+		}
+		b = append(b, line...)
+		b = append(b, '\n')
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(name), dirPerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the records stored in the named file, sorted by time.
+// A missing file returns no records. Corrupt or truncated lines are skipped
+// and reported in the returned error as ErrRecord, along with the valid records.
+func Read(name string) ([]Record, error) {
+	records := []Record{}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var errs []error
+	scanner := bufio.NewScanner(f)
+	for i := 1; scanner.Scan(); i++ {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil || r.IP == "" {
+			errs = append(errs, fmt.Errorf("%s line %d: %w", name, i, ErrRecord))
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, errors.Join(errs...)
+}
+
+// Filter returns the records observed between from and to, inclusive.
+// A zero from or to time is unbounded.
+func Filter(records []Record, from, to time.Time) []Record {
+	filtered := []Record{}
+	for _, r := range records {
+		if !from.IsZero() && r.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && r.Time.After(to) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+// Span is a period of time that an address family held an IP address and location.
+type Span struct {
+	Record            // Record is the first record of the span.
+	Until   time.Time // Until is when a different address was observed, or the last record of a current span.
+	Count   int       // Count is the number of records in the span.
+	Current bool      // Current reports whether the address is the last observed of the family.
+}
+
+// Duration returns how long the address was held.
+func (s Span) Duration() time.Duration {
+	return s.Until.Sub(s.Time)
+}
+
+// Summarise groups the time sorted records into the spans that each address family
+// held an IP address and location. The spans are sorted by their start time.
+func Summarise(records []Record) []Span {
+	spans := []Span{}
+	last := map[string]int{}
+	for _, r := range records {
+		i, ok := last[r.Family]
+		if ok && spans[i].IP == r.IP && spans[i].Location() == r.Location() {
+			spans[i].Until = r.Time
+			spans[i].Count++
+			continue
+		}
+		if ok {
+			spans[i].Until = r.Time
+			spans[i].Current = false
+		}
+		spans = append(spans, Span{Record: r, Until: r.Time, Count: 1, Current: true})
+		last[r.Family] = len(spans) - 1
+	}
+	return spans
+}
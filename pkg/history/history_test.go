@@ -0,0 +1,125 @@
+package history_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/history"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+var start = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC) //nolint: gochecknoglobals
+
+func observe(f provider.Family, ip, city string, hours int) watch.Observation {
+	return watch.Observation{
+		Time:      start.Add(time.Duration(hours) * time.Hour),
+		Family:    f,
+		IP:        ip,
+		Location:  geolite2.Location{City: city, Country: "Australia", ISOCode: "AU"},
+		Providers: []string{"ipify"},
+	}
+}
+
+func TestAppend(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "myip", "history.jsonl")
+	if r, err := history.Read(name); err != nil || len(r) != 0 {
+		t.Fatalf("Read() = %v, %v, want no records", r, err)
+	}
+	if err := history.Append(name, observe(provider.IPv4, "192.0.2.1", "Sydney", 1),
+		observe(provider.IPv6, "", "", 1)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := history.Append(name, observe(provider.IPv4, "192.0.2.2", "Perth", 0)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	r, err := history.Read(name)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(r) != 2 || r[0].IP != "192.0.2.2" || r[1].IP != "192.0.2.1" || r[1].Country != "Australia" {
+		t.Errorf("Read() = %v, want two time sorted records", r)
+	}
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("{}\n{\"time\":\"2022-01-01T10:00:00Z\",\"fami"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	r, err = history.Read(name)
+	if !errors.Is(err, history.ErrRecord) {
+		t.Errorf("Read() error = %v, want %v", err, history.ErrRecord)
+	}
+	if len(r) != 2 {
+		t.Errorf("Read() = %v, want the two valid records", r)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	records := []history.Record{}
+	for i := 0; i < 4; i++ {
+		records = append(records, history.NewRecord(observe(provider.IPv4, "192.0.2.1", "Sydney", i*24)))
+	}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"unbounded", time.Time{}, time.Time{}, 4},
+		{"from", start.Add(24 * time.Hour), time.Time{}, 3},
+		{"to", time.Time{}, start.Add(24 * time.Hour), 2},
+		{"between", start.Add(time.Hour), start.Add(48 * time.Hour), 2},
+		{"none", start.Add(time.Hour), start.Add(2 * time.Hour), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := history.Filter(records, tt.from, tt.to); len(got) != tt.want {
+				t.Errorf("Filter() = %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	records := []history.Record{}
+	for _, o := range []watch.Observation{
+		observe(provider.IPv4, "192.0.2.1", "Sydney", 0),
+		observe(provider.IPv6, "2001:db8::1", "Sydney", 0),
+		observe(provider.IPv4, "192.0.2.1", "Sydney", 2),
+		observe(provider.IPv4, "192.0.2.2", "Sydney", 5),
+		observe(provider.IPv4, "192.0.2.2", "Perth", 6),
+		observe(provider.IPv6, "2001:db8::1", "Sydney", 7),
+		observe(provider.IPv4, "192.0.2.2", "Perth", 9),
+	} {
+		records = append(records, history.NewRecord(o))
+	}
+	want := []struct {
+		ip      string
+		city    string
+		hours   int
+		count   int
+		current bool
+	}{
+		{"192.0.2.1", "Sydney", 5, 2, false},
+		{"2001:db8::1", "Sydney", 7, 2, true},
+		{"192.0.2.2", "Sydney", 1, 1, false},
+		{"192.0.2.2", "Perth", 3, 2, true},
+	}
+	spans := history.Summarise(records)
+	if len(spans) != len(want) {
+		t.Fatalf("Summarise() = %d spans, want %d", len(spans), len(want))
+	}
+	for i, w := range want {
+		s := spans[i]
+		if s.IP != w.ip || s.City != w.city || s.Duration() != time.Duration(w.hours)*time.Hour ||
+			s.Count != w.count || s.Current != w.current {
+			t.Errorf("Summarise()[%d] = %s %s %s %d %v, want %v", i, s.IP, s.City, s.Duration(), s.Count, s.Current, w)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-// Package myipcom returns your Internet-facing IPv4 or IPv6
+// Package ipify returns your Internet-facing IPv4 or IPv6
 // address, sourced from the ipify API.
 // https://www.ipify.org
 // © Ben Garrett https://github.com/bengarrett/myip
@@ -7,11 +7,9 @@ package ipify
 import (
 	"context"
 	"errors"
-	"fmt"
-	"io"
-	"net"
 	"net/http"
-	"strings"
+
+	"github.com/bengarrett/myip/pkg/plaintext"
 )
 
 // https://api.ipify.org
@@ -20,10 +18,11 @@ import (
 // 1.1.1.1
 
 var (
-	ErrNoIP    = errors.New("ip address is empty")
-	ErrInvalid = errors.New("ip address is invalid")
+	ErrNoIP    = plaintext.ErrNoIP
+	ErrInvalid = plaintext.ErrInvalid
+	// Deprecated: ErrRequest is unused, failed requests return a *fault.Error.
 	ErrRequest = errors.New("ipify.org error")
-	ErrStatus  = errors.New("unusual ipify.org server response")
+	ErrStatus  = plaintext.ErrStatus
 )
 
 const (
@@ -32,81 +31,77 @@ const (
 	Linkv6 = "https://api6.ipify.org"
 )
 
+// Client requests the ipify API using a HTTP client and endpoint URLs.
+// The zero value uses http.DefaultClient with the Linkv4 and Linkv6 URLs.
+type Client struct {
+	HTTP   *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Linkv4 string       // Linkv4 is the IPv4 endpoint URL, an empty value uses Linkv4.
+	Linkv6 string       // Linkv6 is the IPv6 endpoint URL, an empty value uses Linkv6.
+}
+
+// URLs returns the IPv4 and IPv6 endpoint URLs used by the client.
+func (c Client) URLs() (string, string) {
+	v4, v6 := c.Linkv4, c.Linkv6
+	if v4 == "" {
+		v4 = Linkv4
+	}
+	if v6 == "" {
+		v6 = Linkv6
+	}
+	return v4, v6
+}
+
 // IPv4 returns the clients online IP address.
 func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	return Request(ctx, cancel, Linkv4)
+	return Client{}.IPv4(ctx, cancel)
 }
 
 // IPv6 returns the clients online IP address. Using this on a network
 // that does not support IPv6 will result in an error.
 func IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	return Request(ctx, cancel, Linkv6)
+	return Client{}.IPv6(ctx, cancel)
 }
 
 // Request the ipify API and return a valid IPv4 or IPv6 address.
 func Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
-	b, err := RequestB(ctx, cancel, url)
-	if b == nil && err == nil && errors.Is(ctx.Err(), context.Canceled) {
-		return "", nil
-	}
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			fmt.Printf("\n%s: timeout", domain)
-			return "", nil
-		}
-		if errors.Is(ctx.Err(), context.Canceled) {
-			return "", nil
-		}
-		if errors.Is(errors.Unwrap(err), context.Canceled) {
-			return "", nil
-		}
-		e := fmt.Errorf("%w: %w", ErrRequest, err)
-		return "", e
-	}
-
-	ip := string(b)
-	if err := Valid(ip); err != nil {
-		return ip, err
-	}
-
-	return ip, nil
+	return Client{}.Request(ctx, cancel, url)
 }
 
 // RequestB requests the ipify API and return the response body.
 func RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byte, error) {
-	defer cancel()
+	return Client{}.RequestB(ctx, cancel, url)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	v4, _ := c.URLs()
+	return c.Request(ctx, cancel, v4)
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	_, v6 := c.URLs()
+	return c.Request(ctx, cancel, v6)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus)
-	}
+// Request the ipify API and return a valid IPv4 or IPv6 address.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	return c.text().Request(ctx, cancel, url)
+}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return b, err
-	}
+// RequestB requests the ipify API and return the response body.
+func (c Client) RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byte, error) {
+	return c.text().RequestB(ctx, cancel, url)
+}
 
-	return b, nil
+// text returns the plain-text client that requests the ipify API.
+func (c Client) text() plaintext.Client {
+	v4, v6 := c.URLs()
+	return plaintext.Client{HTTP: c.HTTP, Domain: domain, Linkv4: v4, Linkv6: v6}
 }
 
 // Valid returns nil if ip is a valid textual representation of an IP address.
 func Valid(ip string) error {
-	if ip == "" {
-		return ErrNoIP
-	}
-	if net.ParseIP(ip) == nil {
-		return ErrInvalid
-	}
-
-	return nil
+	return plaintext.Valid(ip)
 }
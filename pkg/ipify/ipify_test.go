@@ -10,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bengarrett/myip/pkg/fault"
 	"github.com/bengarrett/myip/pkg/ipify"
+	"github.com/bengarrett/myip/pkg/myiptest"
 )
 
 func BenchmarkRequest(b *testing.B) {
@@ -73,8 +75,8 @@ func ExampleIPv6() {
 
 func TestTimeout(t *testing.T) {
 	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
-	if _, err := ipify.IPv4(ctx, timeout); !errors.Is(err, nil) {
-		t.Errorf("IPv4() = %v, want %v", err, nil)
+	if _, err := ipify.IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
 	}
 }
 
@@ -82,11 +84,11 @@ func TestCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 	s, err := ipify.IPv4(ctx, cancel)
-	if s != "" || err != nil {
-		t.Errorf("IPv4() error = %v, want error string", err)
+	if s != "" || !fault.Is(err, fault.Canceled) {
+		t.Errorf("IPv4() s = %v, error = %v, want an empty string with a %v error", s, err, fault.Canceled)
 	}
-	if want := context.Canceled; !errors.Is(ctx.Err(), want) {
-		t.Errorf("IPv4() context.error = %v, want %v", err, want)
+	if want := context.Canceled; !errors.Is(err, want) {
+		t.Errorf("IPv4() error = %v, want %v", err, want)
 	}
 }
 
@@ -98,24 +100,37 @@ func TestError(t *testing.T) {
 }
 
 func TestRequestB(t *testing.T) {
+	ok := myiptest.NewServer(myiptest.Ipify, myiptest.OK)
+	defer ok.Close()
+	html := myiptest.NewServer(myiptest.Ipify, myiptest.Malformed)
+	defer html.Close()
+	status := myiptest.NewServer(myiptest.Ipify, myiptest.Status)
+	defer status.Close()
+	slow := myiptest.NewServer(myiptest.Ipify, myiptest.Slow)
+	defer slow.Close()
 	tests := []struct {
 		name    string
 		domain  string
+		want    string
 		wantErr string
 	}{
-		{"empty", "", "unsupported protocol scheme"},
-		{"html", "https://example.com", ""},
-		{"404", "https://api.ipify.org/abcdef", "404 not found"},
-		{"ipv4", ipify.Linkv4, ""},
-		{"ipv6", ipify.Linkv6, ""},
+		{"empty", "", "", "unsupported protocol scheme"},
+		{"html", html.Linkv4, "<html><body>malformed</body></html>", ""},
+		{"503", status.Linkv4, "", "503 service unavailable"},
+		{"slow", slow.Linkv4, "", "context deadline exceeded"},
+		{"ipv4", ok.Linkv4, myiptest.IPv4, ""},
+		{"ipv6", ok.Linkv6, myiptest.IPv6, ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ctx, timeout := context.WithTimeout(context.Background(), 5*time.Second)
-			_, err := ipify.RequestB(ctx, timeout, tt.domain)
-			if err != nil && tt.wantErr != "" && !strings.Contains(fmt.Sprint(err), tt.wantErr) {
+			ctx, timeout := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			b, err := ipify.RequestB(ctx, timeout, tt.domain)
+			if (err != nil) != (tt.wantErr != "") || err != nil && !strings.Contains(fmt.Sprint(err), tt.wantErr) {
 				t.Errorf("RequestB() error = %v, want %v", err, tt.wantErr)
 			}
+			if got := strings.TrimSpace(string(b)); got != tt.want {
+				t.Errorf("RequestB() = %q, want %q", got, tt.want)
+			}
 		})
 	}
 }
@@ -141,3 +156,41 @@ func TestValid(t *testing.T) {
 		})
 	}
 }
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.OK, true, myiptest.IPv6, nil},
+		{"malformed", myiptest.Malformed, false, "", ipify.ErrInvalid},
+		{"status", myiptest.Status, false, "", ipify.ErrStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.Ipify, tt.behavior)
+			defer srv.Close()
+			c := ipify.Client{HTTP: srv.Client(), Linkv4: srv.Linkv4, Linkv6: srv.Linkv6}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+	c := ipify.Client{Linkv4: "http://localhost"}
+	if v4, v6 := c.URLs(); v4 != "http://localhost" || v6 != ipify.Linkv6 {
+		t.Errorf("URLs() = %v, %v, want %v, %v", v4, v6, "http://localhost", ipify.Linkv6)
+	}
+}
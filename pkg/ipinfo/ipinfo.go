@@ -0,0 +1,230 @@
+// Package ipinfo returns your Internet-facing IPv4 or IPv6 address,
+// sourced from the IPinfo API. The API also reports the hostname,
+// location, organisation and autonomous system number of the address.
+// https://ipinfo.io
+// © Ben Garrett https://github.com/bengarrett/myip
+package ipinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+// https://ipinfo.io/json
+//
+// Output:
+// {
+//   "ip": "93.184.216.34",
+//   "city": "Norwell",
+//   "region": "Massachusetts",
+//   "country": "US",
+//   "loc": "42.1596,-70.8217",
+//   "org": "AS15133 Edgecast Inc.",
+//   "postal": "02061",
+//   "timezone": "America/New_York"
+// }
+
+// Result of query.
+type Result struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname"`
+	City     string `json:"city"`
+	Region   string `json:"region"`
+	Country  string `json:"country"` // Country is the ISO 3166-1 country code.
+	Loc      string `json:"loc"`     // Loc is the latitude and longitude.
+	Org      string `json:"org"`     // Org is the autonomous system number followed by the organisation.
+	Postal   string `json:"postal"`
+	Timezone string `json:"timezone"`
+}
+
+// ASN returns the autonomous system number of the organisation, for example AS15133.
+func (r Result) ASN() string {
+	asn, _, _ := strings.Cut(r.Org, " ")
+	if !strings.HasPrefix(asn, "AS") {
+		return ""
+	}
+	return asn
+}
+
+// Organisation returns the organisation name without the autonomous system number.
+func (r Result) Organisation() string {
+	if asn := r.ASN(); asn != "" {
+		return strings.TrimSpace(strings.TrimPrefix(r.Org, asn))
+	}
+	return r.Org
+}
+
+var (
+	ErrNoIP    = errors.New("ip address is empty")
+	ErrNoIPv4  = errors.New("ip address is not ipv4")
+	ErrNoIPv6  = errors.New("ip address is not ipv6")
+	ErrInvalid = errors.New("ip address is invalid")
+	ErrStatus  = errors.New("unusual ipinfo.io server response")
+)
+
+const (
+	EnvToken = "IPINFO_TOKEN" // EnvToken is the environment variable of an optional access token.
+
+	domain = "ipinfo.io"
+	Linkv4 = "https://ipinfo.io/json"
+	Linkv6 = "https://v6.ipinfo.io/json"
+)
+
+// Client requests the IPinfo API using a HTTP client and endpoint URLs.
+// The zero value uses http.DefaultClient with the Linkv4 and Linkv6 URLs.
+type Client struct {
+	HTTP   *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Token  string       // Token is an optional access token, an empty value uses the IPINFO_TOKEN environment variable.
+	Linkv4 string       // Linkv4 is the IPv4 endpoint URL, an empty value uses Linkv4.
+	Linkv6 string       // Linkv6 is the IPv6 endpoint URL, an empty value uses Linkv6.
+}
+
+func (c Client) client() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
+
+func (c Client) token() string {
+	if c.Token == "" {
+		return os.Getenv(EnvToken)
+	}
+	return c.Token
+}
+
+// URLs returns the IPv4 and IPv6 endpoint URLs used by the client.
+func (c Client) URLs() (string, string) {
+	v4, v6 := c.Linkv4, c.Linkv6
+	if v4 == "" {
+		v4 = Linkv4
+	}
+	if v6 == "" {
+		v6 = Linkv6
+	}
+	return v4, v6
+}
+
+// IPv4 returns the clients online IP address.
+func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return Client{}.IPv4(ctx, cancel)
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return Client{}.IPv6(ctx, cancel)
+}
+
+// RequestR requests the IPinfo API and return the parsed response body.
+func RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
+	return Client{}.RequestR(ctx, cancel, url)
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	r, err := c.ResultV4(ctx, cancel)
+	return r.IP, err
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	r, err := c.ResultV6(ctx, cancel)
+	return r.IP, err
+}
+
+// ResultV4 returns the clients online IP address and its details.
+func (c Client) ResultV4(ctx context.Context, cancel context.CancelFunc) (Result, error) {
+	v4, _ := c.URLs()
+	return c.result(ctx, cancel, v4, false)
+}
+
+// ResultV6 returns the clients online IP address and its details. Using this
+// on a network that does not support IPv6 will result in an error.
+func (c Client) ResultV6(ctx context.Context, cancel context.CancelFunc) (Result, error) {
+	_, v6 := c.URLs()
+	return c.result(ctx, cancel, v6, true)
+}
+
+func (c Client) result(ctx context.Context, cancel context.CancelFunc, url string, ipv6 bool) (Result, error) {
+	r, err := c.RequestR(ctx, cancel, url)
+	if err != nil {
+		return r, fault.New(domain, err)
+	}
+
+	if err := Valid(ipv6, r.IP); err != nil {
+		return r, fault.Wrap(domain, fault.Validation, err)
+	}
+
+	return r, nil
+}
+
+// RequestR requests the IPinfo API and return the parsed response body.
+func (c Client) RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := c.token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fault.Wrap(domain, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
+	}
+
+	r, err := parse(resp.Body)
+	if err != nil {
+		return Result{}, fault.Wrap(domain, fault.Parse, err)
+	}
+
+	return r, nil
+}
+
+func parse(r io.Reader) (Result, error) {
+	var result Result
+	jsonParser := json.NewDecoder(r)
+	if err := jsonParser.Decode(&result); err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+// Valid returns nil if s is a valid textual representation of an IP address of the family.
+func Valid(ipv6 bool, s string) error {
+	if s == "" {
+		return ErrNoIP
+	}
+
+	ip := net.ParseIP(s)
+	switch {
+	case ip == nil:
+		return ErrInvalid
+	case ipv6 && ip.To4() != nil:
+		return ErrNoIPv6
+	case !ipv6 && ip.To4() == nil:
+		return ErrNoIPv4
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package ipinfo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/ipinfo"
+	"github.com/bengarrett/myip/pkg/myiptest"
+)
+
+// ExampleClient_ResultV4 demonstrates an IPinfo request with a 5 second timeout.
+func ExampleClient_ResultV4() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := ipinfo.Client{}.ResultV4(ctx, cancel)
+	if err != nil {
+		log.Printf("\n%s\n", err)
+	}
+	fmt.Println(r.IP, r.ASN(), r.Organisation())
+}
+
+func TestTimeout(t *testing.T) {
+	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
+	if _, err := ipinfo.IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name string
+		org  string
+		asn  string
+		want string
+	}{
+		{"asn", "AS15133 Edgecast Inc.", "AS15133", "Edgecast Inc."},
+		{"no asn", "Edgecast Inc.", "", "Edgecast Inc."},
+		{"asn only", "AS15133", "AS15133", ""},
+		{"empty", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := ipinfo.Result{Org: tt.org}
+			if got := r.ASN(); got != tt.asn {
+				t.Errorf("ASN() = %q, want %q", got, tt.asn)
+			}
+			if got := r.Organisation(); got != tt.want {
+				t.Errorf("Organisation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name string
+		ipv6 bool
+		s    string
+		want error
+	}{
+		{"ipv4", false, myiptest.IPv4, nil},
+		{"ipv6", true, myiptest.IPv6, nil},
+		{"empty", false, "", ipinfo.ErrNoIP},
+		{"invalid", false, "192.0.2", ipinfo.ErrInvalid},
+		{"not ipv4", false, myiptest.IPv6, ipinfo.ErrNoIPv4},
+		{"not ipv6", true, myiptest.IPv4, ipinfo.ErrNoIPv6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ipinfo.Valid(tt.ipv6, tt.s); !errors.Is(err, tt.want) {
+				t.Errorf("Valid() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.OK, true, myiptest.IPv6, nil},
+		{"wrong family", myiptest.WrongFamily, false, "", ipinfo.ErrNoIPv4},
+		{"status", myiptest.Status, false, "", ipinfo.ErrStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.IPinfo, tt.behavior)
+			defer srv.Close()
+			c := ipinfo.Client{HTTP: srv.Client(), Linkv4: srv.Linkv4, Linkv6: srv.Linkv6}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+	srv := myiptest.NewServer(myiptest.IPinfo, myiptest.Malformed)
+	defer srv.Close()
+	c := ipinfo.Client{HTTP: srv.Client(), Linkv4: srv.Linkv4}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if _, err := c.ResultV4(ctx, cancel); !fault.Is(err, fault.Parse) {
+		t.Errorf("ResultV4() error = %v, want the %s phase", err, fault.Parse)
+	}
+	if v4, v6 := (ipinfo.Client{}).URLs(); v4 != ipinfo.Linkv4 || v6 != ipinfo.Linkv6 {
+		t.Errorf("URLs() = %v, %v, want %v, %v", v4, v6, ipinfo.Linkv4, ipinfo.Linkv6)
+	}
+}
+
+func TestClient_token(t *testing.T) {
+	auth := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		fmt.Fprint(w, myiptest.Body(myiptest.IPinfo, false))
+	}))
+	defer srv.Close()
+	t.Setenv(ipinfo.EnvToken, "secret")
+	c := ipinfo.Client{HTTP: srv.Client(), Linkv4: srv.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	r, err := c.ResultV4(ctx, cancel)
+	if err != nil || r.Hostname != "host.example.net" || r.ASN() != "AS64496" {
+		t.Errorf("ResultV4() = %+v, %v, want the result fields", r, err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("ResultV4() Authorization = %q, want %q", auth, "Bearer secret")
+	}
+}
@@ -1,122 +1,58 @@
 // Package ipv4 requests your Internet-facing IPv4 address,
-// sourced from four different online APIs.
+// sourced from the online APIs of the provider registry.
+// It is a wrapper of the resolver package.
 // © Ben Garrett https://github.com/bengarrett/myip
 package ipv4
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"time"
 
-	"github.com/bengarrett/myip/pkg/ipify"
-	"github.com/bengarrett/myip/pkg/myipcom"
-	"github.com/bengarrett/myip/pkg/myipio"
 	"github.com/bengarrett/myip/pkg/ping"
-	"github.com/bengarrett/myip/pkg/seeip"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
 )
 
-type jobs uint8
+var ErrNoIP = resolver.ErrNoIP
 
-const (
-	job1 jobs = iota
-	job2
-	job3
-	job4
-)
+// Stream queries every registered provider for an IPv4 address and
+// sends each result to the returned channel as the replies come in.
+func Stream(ctx context.Context, timeout time.Duration) <-chan provider.Result {
+	return resolver.New(provider.IPv4, timeout).Stream(ctx)
+}
 
-type query struct {
-	complete int
-	results  []string
-	raw      bool
+// Results queries every registered provider for an IPv4 address and
+// returns all the results in the order the replies came in.
+func Results(ctx context.Context, timeout time.Duration) []provider.Result {
+	return resolver.New(provider.IPv4, timeout).Results(ctx)
 }
 
-func (q *query) worker(ctx context.Context, cancel context.CancelFunc, j jobs, c chan string) {
-	ip, err := job(ctx, cancel, j)
-	q.complete++
-	if err != nil {
-		s := ping.Sprints(err.Error(), q.complete, false)
-		if q.complete == 1 {
-			fmt.Fprint(os.Stdout, s)
-		} else {
-			fmt.Fprintf(os.Stdout, "\n%s", s)
-		}
-		c <- ""
-		return
-	}
-	s := ping.Sprints(ip, q.complete, q.raw)
-	newIP := !ping.Contains(q.results, ip)
-	if newIP {
-		q.results = append(q.results, ip)
-	}
-	if newIP && len(q.results) > 1 {
-		fmt.Fprintf(os.Stdout, "\n%s", s)
-	} else {
-		fmt.Fprint(os.Stdout, s)
-	}
-	c <- ip
+// First queries every registered provider for an IPv4 address and
+// returns the result of the quickest successful reply.
+func First(ctx context.Context, timeout time.Duration) (provider.Result, error) {
+	return resolver.New(provider.IPv4, timeout).First(ctx)
 }
 
-// All queries four different services for an IPv4 address and
+// All queries every registered provider for an IPv4 address and
 // as the replies come in, it prints the results to standard output.
 // Enabling raw will exclude the city and country location.
+//
+// Deprecated: Use Stream or Results and leave the printing to the caller.
 func All(timeoutMS int64, raw bool) {
-	q := query{raw: raw}
-	c := make(chan string)
-	timeout := time.Duration(timeoutMS) * time.Millisecond
-	ctx1, cancel1 := context.WithTimeout(context.Background(), timeout)
-	ctx2, cancel2 := context.WithTimeout(context.Background(), timeout)
-	ctx3, cancel3 := context.WithTimeout(context.Background(), timeout)
-	ctx4, cancel4 := context.WithTimeout(context.Background(), timeout)
-	go q.worker(ctx1, cancel1, job1, c)
-	go q.worker(ctx2, cancel2, job2, c)
-	go q.worker(ctx3, cancel3, job3, c)
-	go q.worker(ctx4, cancel4, job4, c)
-	<-c
-	<-c
-	<-c
-	<-c
+	r := resolver.New(provider.IPv4, time.Duration(timeoutMS)*time.Millisecond)
+	ping.Fprint(os.Stdout, r.Stream(context.Background()), r.Len(), raw)
 }
 
-// One queries four different services for an IPv4 address and
+// One queries every registered provider for an IPv4 address and
 // returns the result of the quickest reply. All other requests
 // are then aborted.
+//
+// Deprecated: Use First which also returns the location and any error.
 func One(timeoutMS int64) string {
-	c := make(chan string)
-	timeout := time.Duration(timeoutMS) * time.Millisecond
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	go worker1(ctx, cancel, job1, c)
-	go worker1(ctx, cancel, job2, c)
-	go worker1(ctx, cancel, job3, c)
-	go worker1(ctx, cancel, job4, c)
-	s := ""
-	for {
-		if s != "" {
-			cancel()
-			return s
-		}
-		s = <-c
-	}
-}
-
-func worker1(ctx context.Context, cancel context.CancelFunc, j jobs, c chan string) {
-	s, err := job(ctx, cancel, j)
+	r, err := First(context.Background(), time.Duration(timeoutMS)*time.Millisecond)
 	if err != nil {
-		c <- err.Error()
-	}
-	c <- s
-}
-
-func job(ctx context.Context, cancel context.CancelFunc, j jobs) (string, error) {
-	switch j {
-	case job1:
-		return ipify.IPv4(ctx, cancel)
-	case job2:
-		return myipcom.IPv4(ctx, cancel)
-	case job3:
-		return myipio.IPv4(ctx, cancel)
-	case job4:
-		return seeip.IPv4(ctx, cancel)
+		return ""
 	}
-	return "", nil
+	return r.IP
 }
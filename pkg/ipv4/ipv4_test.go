@@ -1,11 +1,14 @@
 package ipv4_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/bengarrett/myip/pkg/ipv4"
+	"github.com/bengarrett/myip/pkg/provider"
 )
 
 const timeout = 5 * time.Second
@@ -21,3 +24,50 @@ func BenchmarkOne(_ *testing.B) {
 	to := int64(timeout)
 	fmt.Println(ipv4.One(to))
 }
+
+type fake struct {
+	name string
+	ip   string
+	err  error
+}
+
+func (f fake) Name() string                      { return f.name }
+func (f fake) Endpoint(_ provider.Family) string { return "http://localhost" }
+func (f fake) Request(_ context.Context, cancel context.CancelFunc, _ provider.Family) (string, error) {
+	defer cancel()
+	return f.ip, f.err
+}
+
+func TestResults(t *testing.T) {
+	errFake := errors.New("fake error")
+	reg := provider.Default
+	provider.Default = provider.NewRegistry(
+		fake{name: "a", ip: "0.0.0.0"},
+		fake{name: "b", err: errFake},
+	)
+	defer func() { provider.Default = reg }()
+
+	results := ipv4.Results(context.Background(), timeout)
+	if len(results) != 2 {
+		t.Fatalf("Results() = %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.Family != provider.IPv4 {
+			t.Errorf("Results() %s family = %v, want %v", r.Provider, r.Family, provider.IPv4)
+		}
+		switch r.Provider {
+		case "a":
+			if !r.OK() || r.IP != "0.0.0.0" {
+				t.Errorf("Results() a = %v, %v, want 0.0.0.0", r.IP, r.Err)
+			}
+		case "b":
+			if !errors.Is(r.Err, errFake) {
+				t.Errorf("Results() b error = %v, want %v", r.Err, errFake)
+			}
+		}
+	}
+	r, err := ipv4.First(context.Background(), timeout)
+	if err != nil || r.Provider != "a" {
+		t.Errorf("First() = %v, %v, want provider a", r.Provider, err)
+	}
+}
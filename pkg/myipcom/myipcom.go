@@ -13,6 +13,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
 )
 
 // https://api.myip.com
@@ -33,6 +35,7 @@ var (
 	ErrNoIPv4  = errors.New("ip address is not v4")
 	ErrNoIPv6  = errors.New("ip address is not v6")
 	ErrInvalid = errors.New("ip address is invalid")
+	// Deprecated: ErrRequest is unused, failed requests return a *fault.Error.
 	ErrRequest = errors.New("myip.com error")
 	ErrStatus  = errors.New("unusual myip.com server response")
 )
@@ -42,102 +45,142 @@ const (
 	Link   = "https://api.myip.com"
 )
 
-// IPv4 returns the clients online IP address.
-func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	s, err := Request(ctx, cancel, Link)
-	if err != nil {
-		return s, err
-	}
+// Client requests the myipcom API using a HTTP client and endpoint URL.
+// The zero value uses http.DefaultClient with the Link URL.
+type Client struct {
+	HTTP *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Link string       // Link is the endpoint URL for both address families, an empty value uses Link.
+}
 
-	if s == "" {
-		return "", nil
+func (c Client) client() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
 	}
+	return c.HTTP
+}
 
-	if err := Valid(false, s); err != nil {
-		return s, err
+// URL returns the endpoint URL used by the client.
+func (c Client) URL() string {
+	if c.Link == "" {
+		return Link
 	}
+	return c.Link
+}
 
-	return s, nil
+// IPv4 returns the clients online IP address.
+func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return Client{}.IPv4(ctx, cancel)
 }
 
 // IPv6 returns the clients online IP address. Using this on a network
 // that does not support IPv6 will result in an error.
 func IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	s, err := Request(ctx, cancel, Link)
-	if err != nil {
-		return s, err
-	}
+	return Client{}.IPv6(ctx, cancel)
+}
 
-	if s == "" {
-		return "", nil
+// Request the myipcom API and return a valid IPv4 or IPv6 address.
+func Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	return Client{}.Request(ctx, cancel, url)
+}
+
+// RequestS requests the myipcom API and return the parsed response body.
+func RequestS(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	return Client{}.RequestS(ctx, cancel, url)
+}
+
+// RequestR requests the myipcom API and return the parsed response body.
+func RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
+	return Client{}.RequestR(ctx, cancel, url)
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	r, err := c.ResultV4(ctx, cancel)
+	return r.IP, err
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	r, err := c.ResultV6(ctx, cancel)
+	return r.IP, err
+}
+
+// ResultV4 returns the clients online IP address with its country.
+func (c Client) ResultV4(ctx context.Context, cancel context.CancelFunc) (Result, error) {
+	return c.result(ctx, cancel, false)
+}
+
+// ResultV6 returns the clients online IP address with its country. Using this
+// on a network that does not support IPv6 will result in an error.
+func (c Client) ResultV6(ctx context.Context, cancel context.CancelFunc) (Result, error) {
+	return c.result(ctx, cancel, true)
+}
+
+func (c Client) result(ctx context.Context, cancel context.CancelFunc, ipv6 bool) (Result, error) {
+	r, err := c.RequestR(ctx, cancel, c.URL())
+	if err != nil {
+		return r, fault.New(domain, err)
 	}
 
-	if err := Valid(true, s); err != nil {
-		return s, err
+	if err := Valid(ipv6, r.IP); err != nil {
+		return r, fault.Wrap(domain, fault.Validation, err)
 	}
 
-	return s, nil
+	return r, nil
 }
 
 // Request the myipcom API and return a valid IPv4 or IPv6 address.
-func Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
-	s, err := RequestS(ctx, cancel, url)
-	if s == "" && err == nil && errors.Is(ctx.Err(), context.Canceled) {
-		return "", nil
-	}
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	s, err := c.RequestS(ctx, cancel, url)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			fmt.Printf("\n%s: timeout", domain)
-			return "", nil
-		}
-		if err == nil && errors.Is(ctx.Err(), context.Canceled) {
-			return "", nil
-		}
-		if errors.Is(errors.Unwrap(err), context.Canceled) {
-			return "", nil
-		}
-		e := fmt.Errorf("%w: %w", ErrRequest, err)
-		return "", e
+		return "", fault.New(domain, err)
 	}
 
-	return s, err
+	return s, nil
 }
 
 // RequestS requests the myipcom API and return the parsed response body.
-func RequestS(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+func (c Client) RequestS(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	r, err := c.RequestR(ctx, cancel, url)
+	return r.IP, err
+}
+
+// RequestR requests the myipcom API and return the parsed response body.
+func (c Client) RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus)
+		return Result{}, fault.Wrap(domain, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
 	}
 
-	ip, err := parse(resp.Body)
+	r, err := parse(resp.Body)
 	if err != nil {
-		return "", err
+		return Result{}, fault.Wrap(domain, fault.Parse, err)
 	}
 
-	return ip, nil
+	return r, nil
 }
 
-func parse(r io.Reader) (string, error) {
+func parse(r io.Reader) (Result, error) {
 	var result Result
 	jsonParser := json.NewDecoder(r)
 	if err := jsonParser.Decode(&result); err != nil {
-		return "", err
+		return Result{}, err
 	}
 
-	return result.IP, nil
+	return result, nil
 }
 
 // Valid returns nil if s is a valid textual representation of an IP address.
@@ -10,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bengarrett/myip/pkg/fault"
 	"github.com/bengarrett/myip/pkg/myipcom"
+	"github.com/bengarrett/myip/pkg/myiptest"
 )
 
 func BenchmarkRequest(b *testing.B) {
@@ -73,8 +75,8 @@ func ExampleIPv6() {
 
 func TestTimeout(t *testing.T) {
 	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
-	if _, err := myipcom.IPv4(ctx, timeout); !errors.Is(err, nil) {
-		t.Errorf("IPv4() = %v, want %v", err, nil)
+	if _, err := myipcom.IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
 	}
 }
 
@@ -82,11 +84,11 @@ func TestCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 	s, err := myipcom.IPv4(ctx, cancel)
-	if s != "" || err != nil {
-		t.Errorf("IPv4() error = %v, want error string", err)
+	if s != "" || !fault.Is(err, fault.Canceled) {
+		t.Errorf("IPv4() s = %v, error = %v, want an empty string with a %v error", s, err, fault.Canceled)
 	}
-	if want := context.Canceled; !errors.Is(ctx.Err(), want) {
-		t.Errorf("IPv4() context.error = %v, want %v", err, want)
+	if want := context.Canceled; !errors.Is(err, want) {
+		t.Errorf("IPv4() error = %v, want %v", err, want)
 	}
 }
 
@@ -98,6 +100,12 @@ func TestError(t *testing.T) {
 }
 
 func TestRequestS(t *testing.T) {
+	ok := myiptest.NewServer(myiptest.MyIPcom, myiptest.OK)
+	defer ok.Close()
+	html := myiptest.NewServer(myiptest.MyIPcom, myiptest.Malformed)
+	defer html.Close()
+	status := myiptest.NewServer(myiptest.MyIPcom, myiptest.Status)
+	defer status.Close()
 	tests := []struct {
 		name    string
 		domain  string
@@ -105,9 +113,9 @@ func TestRequestS(t *testing.T) {
 		wantErr string
 	}{
 		{"empty", "", false, "unsupported protocol scheme"},
-		{"html", "https://example.com", false, "invalid character"},
-		{"404", "https://api.myip.com" + "/abcdef", false, "404 not found"},
-		{"okay", "https://api.myip.com", true, ""},
+		{"html", html.URL, false, "invalid character"},
+		{"503", status.URL, false, "503 service unavailable"},
+		{"okay", ok.URL, true, ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -144,3 +152,47 @@ func TestValid(t *testing.T) {
 		})
 	}
 }
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.WrongFamily, true, myiptest.IPv6, nil},
+		{"wrong family", myiptest.OK, true, "", myipcom.ErrNoIPv6},
+		{"status", myiptest.Status, false, "", myipcom.ErrStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.MyIPcom, tt.behavior)
+			defer srv.Close()
+			c := myipcom.Client{HTTP: srv.Client(), Link: srv.URL}
+			if got := c.URL(); got != srv.URL {
+				t.Errorf("URL() = %v, want %v", got, srv.URL)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+	srv := myiptest.NewServer(myiptest.MyIPcom, myiptest.OK)
+	defer srv.Close()
+	c := myipcom.Client{HTTP: srv.Client(), Link: srv.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if r, err := c.ResultV4(ctx, cancel); err != nil || r.Country != "Australia" || r.ISOCode != "AU" {
+		t.Errorf("ResultV4() = %+v, %v, want the country fields", r, err)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
 )
 
 // https://api.my-ip.io/ip.json
@@ -37,8 +39,9 @@ var (
 	ErrNoIPv4    = errors.New("ip address is not ipv4")
 	ErrNoIPv6    = errors.New("ip address is not ipv6")
 	ErrInvalid   = errors.New("ip address is invalid")
-	ErrRequest   = errors.New("myip.com error")
-	ErrStatus    = errors.New("unusual my-ip.io server response")
+	// Deprecated: ErrRequest is unused, failed requests return a *fault.Error.
+	ErrRequest = errors.New("myip.com error")
+	ErrStatus  = errors.New("unusual my-ip.io server response")
 )
 
 const (
@@ -47,47 +50,98 @@ const (
 	Linkv6 = "https://api6.my-ip.io/ip.json"
 )
 
+// Client requests the Workshell MyIP API using a HTTP client and endpoint URLs.
+// The zero value uses http.DefaultClient with the Linkv4 and Linkv6 URLs.
+type Client struct {
+	HTTP   *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Linkv4 string       // Linkv4 is the IPv4 endpoint URL, an empty value uses Linkv4.
+	Linkv6 string       // Linkv6 is the IPv6 endpoint URL, an empty value uses Linkv6.
+}
+
+func (c Client) client() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
+
+// URLs returns the IPv4 and IPv6 endpoint URLs used by the client.
+func (c Client) URLs() (string, string) {
+	v4, v6 := c.Linkv4, c.Linkv6
+	if v4 == "" {
+		v4 = Linkv4
+	}
+	if v6 == "" {
+		v6 = Linkv6
+	}
+	return v4, v6
+}
+
 // IPv4 returns the clients online IP address.
 func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	return Request(ctx, cancel, Linkv4)
+	return Client{}.IPv4(ctx, cancel)
 }
 
 // IPv6 returns the clients online IP address. Using this on a network
 // that does not support IPv6 will result in an error.
 func IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	return Request(ctx, cancel, Linkv6)
+	return Client{}.IPv6(ctx, cancel)
 }
 
 // Request the myipcom API and return a valid IPv4 or IPv6 address.
 func Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
-	r, err := RequestR(ctx, cancel, url)
-	if r.IP == "" && err == nil && errors.Is(ctx.Err(), context.Canceled) {
-		return "", nil
+	return Client{}.Request(ctx, cancel, url)
+}
+
+// RequestR requests the myipcom API and return the parsed response body.
+func RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
+	return Client{}.RequestR(ctx, cancel, url)
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	v4, _ := c.URLs()
+	return c.family(ctx, cancel, v4, false)
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	_, v6 := c.URLs()
+	return c.family(ctx, cancel, v6, true)
+}
+
+// family requests the url and validates the result against the address family.
+func (c Client) family(ctx context.Context, cancel context.CancelFunc, url string, ipv6 bool) (string, error) {
+	r, err := c.RequestR(ctx, cancel, url)
+	if err != nil {
+		return "", fault.New(domain, err)
+	}
+	if err := r.Family(ipv6); err != nil {
+		return r.IP, fault.Wrap(domain, fault.Validation, err)
 	}
+	return r.IP, nil
+}
+
+// Request the myipcom API and return a valid IPv4 or IPv6 address.
+// The address family is only checked when the url is one of the client
+// endpoints and the two endpoints differ, use IPv4 or IPv6 to always check it.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	r, err := c.RequestR(ctx, cancel, url)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			fmt.Printf("\n%s: timeout", domain)
-			return "", nil
-		}
-		if err == nil && errors.Is(ctx.Err(), context.Canceled) {
-			return "", nil
-		}
-		if errors.Is(errors.Unwrap(err), context.Canceled) {
-			return "", nil
-		}
-		e := fmt.Errorf("%w: %w", ErrRequest, err)
-		return "", e
+		return "", fault.New(domain, err)
 	}
 
-	if err := r.Valid(url); err != nil {
-		return r.IP, err
+	v4, v6 := c.URLs()
+	if err := r.valid(url == v4 && v4 != v6, url == v6 && v4 != v6); err != nil {
+		return r.IP, fault.Wrap(domain, fault.Validation, err)
 	}
 
 	return r.IP, nil
 }
 
 // RequestR requests the myipcom API and return the parsed response body.
-func RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
+func (c Client) RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Result, error) {
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -95,19 +149,19 @@ func RequestR(ctx context.Context, cancel context.CancelFunc, url string) (Resul
 		return Result{}, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
 		return Result{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return Result{}, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus)
+		return Result{}, fault.Wrap(domain, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
 	}
 
 	r, err := parse(resp.Body)
 	if err != nil {
-		return Result{}, err
+		return Result{}, fault.Wrap(domain, fault.Parse, err)
 	}
 
 	return r, nil
@@ -124,7 +178,19 @@ func parse(r io.Reader) (Result, error) {
 }
 
 // Valid returns nil if url is a valid textual representation of an IP address.
+// The address family is only checked when url is the package Linkv4 or Linkv6,
+// use Family to check the results of any other url.
 func (r Result) Valid(url string) error {
+	return r.valid(url == Linkv4, url == Linkv6)
+}
+
+// Family returns nil if the result is a valid IP address of the address family.
+func (r Result) Family(ipv6 bool) error {
+	return r.valid(!ipv6, ipv6)
+}
+
+// valid returns nil if the result is a valid IP address of the expected type.
+func (r Result) valid(ipv4, ipv6 bool) error {
 	if r.IP == "" {
 		return ErrNoIP
 	}
@@ -133,10 +199,10 @@ func (r Result) Valid(url string) error {
 		return ErrNoSuccess
 	}
 
-	if url == Linkv4 && !strings.EqualFold(r.Type, "ipv4") {
+	if ipv4 && !strings.EqualFold(r.Type, "ipv4") {
 		return ErrNoIPv4
 	}
-	if url == Linkv6 && !strings.EqualFold(r.Type, "ipv6") {
+	if ipv6 && !strings.EqualFold(r.Type, "ipv6") {
 		return ErrNoIPv6
 	}
 
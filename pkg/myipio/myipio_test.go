@@ -10,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bengarrett/myip/pkg/fault"
 	"github.com/bengarrett/myip/pkg/myipio"
+	"github.com/bengarrett/myip/pkg/myiptest"
 )
 
 func BenchmarkRequest(b *testing.B) {
@@ -73,8 +75,8 @@ func ExampleIPv6() {
 
 func TestTimeout(t *testing.T) {
 	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
-	if _, err := myipio.IPv4(ctx, timeout); !errors.Is(err, nil) {
-		t.Errorf("IPv4() = %v, want %v", err, nil)
+	if _, err := myipio.IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
 	}
 }
 
@@ -82,11 +84,11 @@ func TestCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 	s, err := myipio.IPv4(ctx, cancel)
-	if s != "" || err != nil {
-		t.Errorf("IPv4() s = %v, error = %v, want an empty string with no errors", s, err)
+	if s != "" || !fault.Is(err, fault.Canceled) {
+		t.Errorf("IPv4() s = %v, error = %v, want an empty string with a %v error", s, err, fault.Canceled)
 	}
-	if want := context.Canceled; !errors.Is(ctx.Err(), want) {
-		t.Errorf("IPv4() context.error = %v, want %v", err, want)
+	if want := context.Canceled; !errors.Is(err, want) {
+		t.Errorf("IPv4() error = %v, want %v", err, want)
 	}
 }
 
@@ -98,6 +100,12 @@ func TestError(t *testing.T) {
 }
 
 func TestRequestR(t *testing.T) {
+	ok := myiptest.NewServer(myiptest.MyIPio, myiptest.OK)
+	defer ok.Close()
+	html := myiptest.NewServer(myiptest.MyIPio, myiptest.Malformed)
+	defer html.Close()
+	status := myiptest.NewServer(myiptest.MyIPio, myiptest.Status)
+	defer status.Close()
 	tests := []struct {
 		name    string
 		domain  string
@@ -105,9 +113,9 @@ func TestRequestR(t *testing.T) {
 		wantErr string
 	}{
 		{"empty", "", false, "unsupported protocol scheme"},
-		{"html", "https://example.com", false, "invalid character"},
-		{"404", "https://api4.my-ip.io/ip.json/abcdef", false, "404 not found"},
-		{"okay", "https://api4.my-ip.io/ip.json", true, ""},
+		{"html", html.Linkv4, false, "invalid character"},
+		{"503", status.Linkv4, false, "503 service unavailable"},
+		{"okay", ok.Linkv4, true, ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -157,3 +165,43 @@ func TestResult_valid(t *testing.T) {
 		})
 	}
 }
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		shared   bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.OK, true, false, myiptest.IPv6, nil},
+		{"not ipv4", myiptest.WrongFamily, false, false, "", myipio.ErrNoIPv4},
+		{"not ipv6", myiptest.WrongFamily, true, false, "", myipio.ErrNoIPv6},
+		{"shared ipv4", myiptest.OK, false, true, myiptest.IPv4, nil},
+		{"shared not ipv6", myiptest.OK, true, true, "", myipio.ErrNoIPv6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.MyIPio, tt.behavior)
+			defer srv.Close()
+			c := myipio.Client{HTTP: srv.Client(), Linkv4: srv.Linkv4, Linkv6: srv.Linkv6}
+			if tt.shared {
+				c.Linkv6 = c.Linkv4
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package myiptest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/bengarrett/myip/pkg/dnsip"
+)
+
+// Subnet is the Google client subnet string that precedes the address in TXT answers.
+const Subnet = "edns0-client-subnet 192.0.2.0/24"
+
+// DNSServer is a local DNS server emulating the DNS echo services over UDP and TCP.
+// A and TXT questions are answered with the IPv4 address and AAAA questions
+// with the IPv6 address.
+type DNSServer struct {
+	Addr string // Addr is the host and port of both the UDP and TCP listeners.
+
+	b        Behavior
+	truncate bool
+	udp      net.PacketConn
+	tcp      net.Listener
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewDNSServer starts and returns a DNS server on the loopback interface with the behavior.
+// When truncate is true, every UDP response is truncated so the client must retry over TCP.
+// The caller should call Close when finished, to shut it down.
+func NewDNSServer(b Behavior, truncate bool) *DNSServer {
+	s := &DNSServer{b: b, truncate: truncate, done: make(chan struct{})}
+	for i := 0; ; i++ {
+		udp, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		if err != nil {
+			panic(fmt.Sprintf("myiptest: failed to listen on a udp port: %v", err))
+		}
+		// the tcp listener must share the port of the udp listener
+		tcp, err := net.Listen("tcp4", udp.LocalAddr().String())
+		if err != nil {
+			udp.Close()
+			if i < 10 {
+				continue
+			}
+			panic(fmt.Sprintf("myiptest: failed to listen on a tcp port: %v", err))
+		}
+		s.udp, s.tcp, s.Addr = udp, tcp, udp.LocalAddr().String()
+		break
+	}
+	s.wg.Add(2)
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+// Link returns a DNS URI link of the question to the server.
+func (s *DNSServer) Link(name, class, typ string) string {
+	return fmt.Sprintf("dns://%s/%s?class=%s&type=%s", s.Addr, name, class, typ)
+}
+
+// Close shuts down the server and blocks until its listeners have stopped.
+func (s *DNSServer) Close() {
+	close(s.done)
+	s.udp.Close()
+	s.tcp.Close()
+	s.wg.Wait()
+}
+
+func (s *DNSServer) serveUDP() {
+	defer s.wg.Done()
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if b := s.reply(buf[:n], s.truncate); b != nil {
+			_, _ = s.udp.WriteTo(b, addr)
+		}
+	}
+}
+
+func (s *DNSServer) serveTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			go func() {
+				<-s.done
+				conn.Close()
+			}()
+			size := make([]byte, 2)
+			if _, err := io.ReadFull(conn, size); err != nil {
+				return
+			}
+			buf := make([]byte, binary.BigEndian.Uint16(size))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			if b := s.reply(buf, false); b != nil {
+				_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(b))), b...))
+			}
+		}()
+	}
+}
+
+// reply returns the response to the query, or nil for no response.
+func (s *DNSServer) reply(query []byte, truncate bool) []byte {
+	q, err := dnsip.Unpack(query)
+	if err != nil {
+		return nil
+	}
+	m := dnsip.Message{ID: q.ID, Flags: dnsip.FlagQR | q.Flags&dnsip.FlagRD, Questions: q.Questions}
+	switch s.b {
+	case Slow:
+		return nil
+	case Malformed:
+		return []byte("<html><body>malformed</body></html>")
+	case Status:
+		const servfail = 2
+		m.Flags |= servfail
+	case OK, WrongFamily:
+		if truncate {
+			m.Flags |= dnsip.FlagTC
+			break
+		}
+		for _, x := range q.Questions {
+			m.Answers = append(m.Answers, answers(x, s.b == WrongFamily)...)
+		}
+	}
+	b, err := m.Pack()
+	if err != nil {
+		panic(fmt.Sprintf("myiptest: failed to pack a dns message: %v", err))
+	}
+	return b
+}
+
+func answers(q dnsip.Question, wrong bool) []dnsip.Record {
+	v4, v6 := net.ParseIP(IPv4).To4(), net.ParseIP(IPv6)
+	r := dnsip.Record{Name: q.Name, Type: q.Type, Class: q.Class}
+	switch q.Type {
+	case dnsip.TypeA, dnsip.TypeAAAA:
+		ipv6 := q.Type == dnsip.TypeAAAA
+		if wrong {
+			ipv6 = !ipv6
+		}
+		r.Type, r.Data = dnsip.TypeA, v4
+		if ipv6 {
+			r.Type, r.Data = dnsip.TypeAAAA, v6
+		}
+		return []dnsip.Record{r}
+	case dnsip.TypeTXT:
+		ip := IPv4
+		if wrong {
+			ip = IPv6
+		}
+		subnet := r
+		subnet.Data = append([]byte{byte(len(Subnet))}, Subnet...)
+		r.Data = append([]byte{byte(len(ip))}, ip...)
+		return []dnsip.Record{subnet, r}
+	}
+	return nil
+}
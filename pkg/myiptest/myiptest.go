@@ -0,0 +1,163 @@
+// Package myiptest provides local HTTP servers that emulate the
+// online APIs of the providers, for use in offline tests.
+// © Ben Garrett https://github.com/bengarrett/myip
+package myiptest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/cftrace"
+	"github.com/bengarrett/myip/pkg/ipify"
+	"github.com/bengarrett/myip/pkg/ipinfo"
+	"github.com/bengarrett/myip/pkg/myipcom"
+	"github.com/bengarrett/myip/pkg/myipio"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/seeip"
+)
+
+const (
+	IPv4 = "192.0.2.1"   // IPv4 is the address returned by the servers, it is reserved for documentation.
+	IPv6 = "2001:db8::1" // IPv6 is the address returned by the servers, it is reserved for documentation.
+
+	Slowdown = 10 * time.Second // Slowdown is the reply delay of a Slow server.
+)
+
+// API is the response format of an online provider.
+type API uint8
+
+const (
+	Ipify   API = iota // Ipify replies with a plain text address.
+	MyIPcom            // MyIPcom replies with a JSON object containing an address, country and country code.
+	MyIPio             // MyIPio replies with a JSON object containing a success flag, address and type.
+	SeeIP              // SeeIP replies with a plain text address.
+	CFTrace            // CFTrace replies with newline separated key=value pairs including the address.
+	IPinfo             // IPinfo replies with a JSON object containing an address, hostname, location and organisation.
+)
+
+// Behavior is how a server answers a request.
+type Behavior uint8
+
+const (
+	OK          Behavior = iota // OK replies with a valid address of the requested family.
+	Slow                        // Slow replies after the Slowdown delay or once the request is canceled.
+	Malformed                   // Malformed replies with a body that cannot be parsed.
+	Status                      // Status replies with a 503 Service Unavailable status.
+	WrongFamily                 // WrongFamily replies with an address of the other family.
+)
+
+// Server is a local HTTP server emulating an online provider.
+// Requests to Linkv4 reply with an IPv4 address and requests
+// to Linkv6 reply with an IPv6 address.
+type Server struct {
+	*httptest.Server
+	Linkv4 string // Linkv4 is the IPv4 endpoint URL.
+	Linkv6 string // Linkv6 is the IPv6 endpoint URL.
+}
+
+// NewServer starts and returns a server emulating the API with the behavior.
+// The caller should call Close when finished, to shut it down.
+func NewServer(api API, b Behavior) *Server {
+	srv := httptest.NewServer(Handler(api, b))
+	return &Server{
+		Server: srv,
+		Linkv4: srv.URL + "/v4",
+		Linkv6: srv.URL + "/v6",
+	}
+}
+
+// Handler returns a HTTP handler emulating the API with the behavior.
+// The /v6 path replies with an IPv6 address and every other path an IPv4 address.
+func Handler(api API, b Behavior) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ipv6 := r.URL.Path == "/v6"
+		if b == WrongFamily {
+			ipv6 = !ipv6
+		}
+		switch b {
+		case Slow:
+			select {
+			case <-time.After(Slowdown):
+			case <-r.Context().Done():
+				return
+			}
+		case Status:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		case Malformed:
+			fmt.Fprint(w, "<html><body>malformed</body></html>")
+			return
+		case OK, WrongFamily:
+		}
+		fmt.Fprint(w, Body(api, ipv6))
+	})
+}
+
+// Body returns a valid response body of the API.
+func Body(api API, ipv6 bool) string {
+	ip, typ := IPv4, "IPv4"
+	if ipv6 {
+		ip, typ = IPv6, "IPv6"
+	}
+	switch api {
+	case MyIPcom:
+		return fmt.Sprintf(`{"ip":%q,"country":"Australia","cc":"AU"}`, ip)
+	case MyIPio:
+		return fmt.Sprintf(`{"success":true,"ip":%q,"type":%q}`, ip, typ)
+	case CFTrace:
+		return fmt.Sprintf("fl=29f21\nh=1.1.1.1\nip=%s\nts=1650000000.000\nvisit_scheme=https\n"+
+			"colo=SYD\nhttp=http/1.1\nloc=AU\ntls=TLSv1.3\nsni=off\nwarp=on\ngateway=off\n", ip)
+	case IPinfo:
+		return fmt.Sprintf(`{"ip":%q,"hostname":"host.example.net","city":"Sydney","region":"New South Wales",`+
+			`"country":"AU","loc":"-33.8678,151.2073","org":"AS64496 Example Networks",`+
+			`"postal":"2000","timezone":"Australia/Sydney"}`, ip)
+	case Ipify, SeeIP:
+	}
+	return ip
+}
+
+// TB is the part of testing.TB used by the helpers that register
+// their servers for shut down, it avoids importing the testing package.
+type TB interface {
+	Helper()
+	Cleanup(f func())
+	Skip(args ...any)
+}
+
+// Provider returns a provider using a new server emulating the API with the behavior.
+// The server is shut down when the test and all its subtests complete.
+func Provider(tb TB, api API, b Behavior) provider.Provider {
+	tb.Helper()
+	srv := NewServer(api, b)
+	tb.Cleanup(srv.Close)
+	c := srv.Client()
+	switch api {
+	case MyIPcom:
+		// myip.com uses a single endpoint that replies with an IPv4 address
+		return provider.MyIPcom(myipcom.Client{HTTP: c, Link: srv.URL})
+	case MyIPio:
+		return provider.MyIPio(myipio.Client{HTTP: c, Linkv4: srv.Linkv4, Linkv6: srv.Linkv6})
+	case SeeIP:
+		return provider.SeeIP(seeip.Client{HTTP: c, Linkv4: srv.Linkv4, Linkv6: srv.Linkv6})
+	case CFTrace:
+		return provider.CFTrace(cftrace.Client{HTTP: c, Linkv4: srv.Linkv4, Linkv6: srv.Linkv6})
+	case IPinfo:
+		return provider.IPinfo(ipinfo.Client{HTTP: c, Linkv4: srv.Linkv4, Linkv6: srv.Linkv6})
+	case Ipify:
+	}
+	return provider.Ipify(ipify.Client{HTTP: c, Linkv4: srv.Linkv4, Linkv6: srv.Linkv6})
+}
+
+// Registry returns a registry of the ipify, myip.com, my-ip.io and seeip
+// providers, each using a new server emulating its API with the behavior.
+func Registry(tb TB, b Behavior) *provider.Registry {
+	tb.Helper()
+	return provider.NewRegistry(
+		Provider(tb, Ipify, b),
+		Provider(tb, MyIPcom, b),
+		Provider(tb, MyIPio, b),
+		Provider(tb, SeeIP, b),
+	)
+}
@@ -0,0 +1,218 @@
+package myiptest
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bengarrett/myip/pkg/stun"
+)
+
+// STUNServer is a local STUN server on the loopback interface.
+// Binding requests are answered with a XOR-MAPPED-ADDRESS containing
+// the IPv4 address and the source port of the request.
+type STUNServer struct {
+	Addr string // Addr is the host and port of the server.
+	Link string // Link is the STUN link of the server.
+
+	b         Behavior
+	mapping   stun.Behavior
+	filtering stun.Behavior
+	conns     []*net.UDPConn // conns are the primary, alternate port, alternate ip, and alternate ip and port sockets.
+	drop      atomic.Int32
+	wg        sync.WaitGroup
+
+	mu   sync.Mutex
+	sent map[uint16][]int // sent are the sockets each emulated mapping has sent requests to.
+}
+
+// NewSTUNServer starts and returns a STUN server with the behavior.
+// The caller should call Close when finished, to shut it down.
+func NewSTUNServer(b Behavior) *STUNServer {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		panic(fmt.Sprintf("myiptest: failed to listen on a udp port: %v", err))
+	}
+	return start(&STUNServer{b: b, conns: []*net.UDPConn{conn}})
+}
+
+// NATServer starts and returns a RFC 5780 STUN server that emulates a NAT with the mapping
+// and filtering behaviors between itself and the client. The server listens on two ports of
+// both 127.0.0.1 and 127.0.0.2, and it replies to the CHANGE-REQUEST and OTHER-ADDRESS attributes.
+// The test is skipped when the second loopback address is unavailable, such as on macOS.
+// The server is shut down when the test and all its subtests complete.
+func NATServer(tb TB, mapping, filtering stun.Behavior) *STUNServer {
+	tb.Helper()
+	const attempts = 10
+	for i := 0; i < attempts; i++ {
+		conns, err := listen()
+		if err != nil {
+			continue
+		}
+		s := start(&STUNServer{b: OK, mapping: mapping, filtering: filtering, conns: conns, sent: map[uint16][]int{}})
+		tb.Cleanup(s.Close)
+		return s
+	}
+	tb.Skip("myiptest: cannot listen on both 127.0.0.1 and 127.0.0.2")
+	return nil
+}
+
+// listen returns the four sockets of a RFC 5780 server.
+func listen() ([]*net.UDPConn, error) {
+	conns := []*net.UDPConn{}
+	fail := func(err error) ([]*net.UDPConn, error) {
+		for _, c := range conns {
+			c.Close()
+		}
+		return nil, err
+	}
+	ips := []net.IP{net.IPv4(127, 0, 0, 1), net.IPv4(127, 0, 0, 2)}
+	ports := []int{0, 0}
+	for i, addr := range []struct{ ip, port int }{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: ips[addr.ip], Port: ports[addr.port]})
+		if err != nil {
+			return fail(err)
+		}
+		conns = append(conns, conn)
+		if i < 2 {
+			// the alternate ip address reuses the primary and alternate ports
+			ports[i] = conn.LocalAddr().(*net.UDPAddr).Port
+		}
+	}
+	return conns, nil
+}
+
+func start(s *STUNServer) *STUNServer {
+	s.Addr = s.conns[0].LocalAddr().String()
+	s.Link = "stun:" + s.Addr
+	for i := range s.conns {
+		s.wg.Add(1)
+		go s.serve(i)
+	}
+	return s
+}
+
+// Drop ignores the next n requests, to test the retransmissions of a client.
+func (s *STUNServer) Drop(n int) {
+	s.drop.Store(int32(n))
+}
+
+// Close shuts down the server and blocks until it has stopped.
+func (s *STUNServer) Close() {
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.wg.Wait()
+}
+
+func (s *STUNServer) serve(i int) {
+	defer s.wg.Done()
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := s.conns[i].ReadFromUDPAddrPort(buf)
+		if err != nil {
+			return
+		}
+		req, err := stun.Unpack(buf[:n])
+		if err != nil || req.Type != stun.BindingRequest {
+			continue
+		}
+		if s.drop.Add(-1) >= 0 {
+			continue
+		}
+		if len(s.conns) > 1 {
+			s.nat(i, req, from)
+			continue
+		}
+		if b := s.reply(req, from); b != nil {
+			_, _ = s.conns[i].WriteToUDPAddrPort(b, from)
+		}
+	}
+}
+
+// reply returns the response to the request, or nil for no response.
+func (s *STUNServer) reply(req stun.Message, from netip.AddrPort) []byte {
+	res := stun.Message{Type: stun.BindingSuccess, ID: req.ID}
+	ip := IPv4
+	switch s.b {
+	case Slow:
+		return nil
+	case Malformed:
+		return []byte("<html><body>malformed</body></html>")
+	case Status:
+		res.Type = stun.BindingError
+		res.Attributes = []stun.Attribute{{Type: stun.AttrErrorCode, Value: []byte("\x00\x00\x05\x00Server Error")}}
+		return res.Pack()
+	case WrongFamily:
+		ip = IPv6
+	case OK:
+	}
+	mapped := netip.AddrPortFrom(netip.MustParseAddr(ip), from.Port())
+	res.Attributes = []stun.Attribute{stun.AddressAttr(stun.AttrXORMappedAddress, mapped, req.ID)}
+	return res.Pack()
+}
+
+// nat replies to a request received on the socket, as seen through the emulated NAT.
+// The socket index uses bit 1 for the alternate ip address and bit 0 for the alternate port.
+func (s *STUNServer) nat(i int, req stun.Message, from netip.AddrPort) {
+	const altIP, altPort = 2, 1
+	// the emulated nat maps the client to a port that depends on the mapping behavior
+	port := from.Port()
+	switch s.mapping {
+	case stun.AddressDependent:
+		port += uint16(i&altIP) * 1000
+	case stun.AddressPortDependent:
+		port += uint16(i) * 1000
+	case stun.Unknown, stun.EndpointIndependent:
+	}
+	s.mu.Lock()
+	s.sent[port] = append(s.sent[port], i)
+	sent := s.sent[port]
+	s.mu.Unlock()
+
+	j := i
+	flags := req.Flags()
+	if flags&stun.ChangeIP != 0 {
+		j ^= altIP
+	}
+	if flags&stun.ChangePort != 0 {
+		j ^= altPort
+	}
+	// the emulated nat drops the responses from any unexpected remote address
+	if !filter(s.filtering, j, sent) {
+		return
+	}
+	addr := func(k int) netip.AddrPort {
+		return netip.MustParseAddrPort(s.conns[k].LocalAddr().String())
+	}
+	mapped := netip.AddrPortFrom(netip.MustParseAddr(IPv4), port)
+	res := stun.Message{Type: stun.BindingSuccess, ID: req.ID, Attributes: []stun.Attribute{
+		stun.AddressAttr(stun.AttrXORMappedAddress, mapped, req.ID),
+		stun.AddressAttr(stun.AttrResponseOrigin, addr(j), req.ID),
+		stun.AddressAttr(stun.AttrOtherAddress, addr(altIP|altPort), req.ID),
+	}}
+	_, _ = s.conns[j].WriteToUDPAddrPort(res.Pack(), from)
+}
+
+// filter reports whether the emulated nat accepts a packet from the socket,
+// using the sockets that the mapping has previously sent requests to.
+func filter(b stun.Behavior, socket int, sent []int) bool {
+	const altIP = 2
+	for _, x := range sent {
+		switch b {
+		case stun.Unknown, stun.EndpointIndependent:
+			return true
+		case stun.AddressDependent:
+			if x&altIP == socket&altIP {
+				return true
+			}
+		case stun.AddressPortDependent:
+			if x == socket {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Package notify stores the last known Internet-facing IP addresses
+// and runs the actions that are triggered when an address changes.
+// © Ben Garrett https://github.com/bengarrett/myip
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+var ErrCommand = errors.New("on-change command is empty")
+
+// Event is a change of the IP address of an address family.
+type Event struct {
+	Old     Entry             // Old is the previous address, its IP is empty on the first run.
+	New     watch.Observation // New is the observation of the changed address.
+	Pending []string          // Pending are the actions left to run, an empty value runs every action.
+}
+
+// Runs reports whether the named action should run for the event.
+func (e Event) Runs(action string) bool {
+	if len(e.Pending) == 0 {
+		return true
+	}
+	for _, x := range e.Pending {
+		if x == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns the event as environment variables for a command.
+func (e Event) Env() []string {
+	return []string{
+		"MYIP_FAMILY=" + e.New.Family.String(),
+		"MYIP_OLD=" + e.Old.IP,
+		"MYIP_NEW=" + e.New.IP,
+		"MYIP_CITY=" + e.New.Location.City,
+		"MYIP_COUNTRY=" + e.New.Location.Country,
+		"MYIP_COUNTRY_CODE=" + e.New.Location.ISOCode,
+		"MYIP_OLD_CITY=" + e.Old.City,
+		"MYIP_OLD_COUNTRY=" + e.Old.Country,
+		"MYIP_NEW_PROVIDERS=" + strings.Join(e.New.Providers, ","),
+		"MYIP_TIME=" + e.New.Time.Format(time.RFC3339),
+	}
+}
+
+// Command is an external program that is run by the system shell on an address change.
+type Command struct {
+	Line    string        // Line is the command line passed to the shell.
+	Timeout time.Duration // Timeout kills the command when exceeded, zero has no limit.
+	Stdout  io.Writer     // Stdout of the command, a nil value discards the output.
+	Stderr  io.Writer     // Stderr of the command, a nil value discards the output.
+}
+
+// Run the command with the event passed as environment variables and return its exit code.
+// A command that cannot be started or is killed on a timeout returns an error.
+func (c Command) Run(ctx context.Context, e Event) (int, error) {
+	if strings.TrimSpace(c.Line) == "" {
+		return -1, ErrCommand
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	name, args := "/bin/sh", []string{"-c", c.Line}
+	if runtime.GOOS == "windows" {
+		name, args = "cmd", []string{"/C", c.Line}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), e.Env()...)
+	cmd.Stdout, cmd.Stderr = c.Stdout, c.Stderr
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return -1, fmt.Errorf("%q: %w", c.Line, ctx.Err())
+	}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, fmt.Errorf("%q: %w", c.Line, err)
+	}
+	return 0, nil
+}
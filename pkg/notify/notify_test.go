@@ -0,0 +1,101 @@
+package notify_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/notify"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+func observe(f provider.Family, ip string) watch.Observation {
+	return watch.Observation{
+		Time:      time.Now(),
+		Family:    f,
+		IP:        ip,
+		Location:  geolite2.Location{City: "Sydney", Country: "Australia", ISOCode: "AU"},
+		Providers: []string{"ipify", "seeip"},
+	}
+}
+
+func TestState(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "myip", "last.json")
+	s, err := notify.Load(name)
+	if err != nil || len(s) != 0 {
+		t.Fatalf("Load() = %v, %v, want an empty state", s, err)
+	}
+	events := s.Update(observe(provider.IPv4, "192.0.2.1"), observe(provider.IPv6, ""))
+	if len(events) != 1 || events[0].Old.IP != "" || events[0].New.IP != "192.0.2.1" {
+		t.Errorf("Update() = %v, want a single first run event", events)
+	}
+	if err := s.Save(name); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	s, err = notify.Load(name)
+	if err != nil || s["ipv4"].IP != "192.0.2.1" || s["ipv4"].Country != "Australia" {
+		t.Fatalf("Load() = %v, %v, want the saved state", s, err)
+	}
+	if events := s.Update(observe(provider.IPv4, "192.0.2.1")); len(events) != 0 {
+		t.Errorf("Update() = %v, want no events", events)
+	}
+	events = s.Update(observe(provider.IPv4, "192.0.2.2"))
+	if len(events) != 1 || events[0].Old.IP != "192.0.2.1" || events[0].New.IP != "192.0.2.2" {
+		t.Errorf("Update() = %v, want a change event", events)
+	}
+	s.Defer(events[0], "https://example.com/hook")
+	events = s.Update(observe(provider.IPv4, "192.0.2.2"))
+	if len(events) != 1 || events[0].Old.IP != "192.0.2.1" || len(events[0].Pending) != 1 {
+		t.Fatalf("Update() = %v, want the pending change event", events)
+	}
+	if events[0].Runs("command") || !events[0].Runs("https://example.com/hook") {
+		t.Errorf("Runs() = %v, want only the pending webhook", events[0].Pending)
+	}
+	if events := s.Update(observe(provider.IPv4, "192.0.2.2")); len(events) != 0 {
+		t.Errorf("Update() = %v, want no events once the pending actions are cleared", events)
+	}
+	if e := (notify.Event{}); !e.Runs("command") {
+		t.Error("Runs() = false, want every action to run for a new change")
+	}
+}
+
+func TestCommand_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a posix shell")
+	}
+	e := notify.Event{Old: notify.Entry{IP: "192.0.2.1"}, New: observe(provider.IPv4, "192.0.2.2")}
+	tests := []struct {
+		name    string
+		line    string
+		want    int
+		stdout  string
+		wantErr bool
+	}{
+		{"empty", " ", -1, "", true},
+		{"env", `echo "$MYIP_OLD>$MYIP_NEW $MYIP_CITY $MYIP_COUNTRY $MYIP_NEW_PROVIDERS"`, 0,
+			"192.0.2.1>192.0.2.2 Sydney Australia ipify,seeip\n", false},
+		{"exit status", "exit 3", 3, "", false},
+		{"timeout", "sleep 5", -1, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := notify.Command{Line: tt.line, Timeout: 500 * time.Millisecond, Stdout: &out}
+			got, err := c.Run(context.Background(), e)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Run() = %d, want %d", got, tt.want)
+			}
+			if s := out.String(); s != tt.stdout {
+				t.Errorf("Run() stdout = %q, want %q", s, tt.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/statedir"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+const (
+	dirPerm  = 0o755
+	filePerm = 0o644
+)
+
+// Entry is the last known IP address and location of an address family.
+type Entry struct {
+	IP      string    `json:"ip"`
+	City    string    `json:"city,omitempty"`
+	Country string    `json:"country,omitempty"`
+	ISOCode string    `json:"country_code,omitempty"`
+	Time    time.Time `json:"time"`
+
+	Pending []string `json:"pending,omitempty"`  // Pending are the actions that failed to deliver the change to the IP.
+	Prev    *Entry   `json:"previous,omitempty"` // Prev is the address before the change of a pending entry.
+}
+
+// NewEntry returns the entry of the observation.
+func NewEntry(o watch.Observation) Entry {
+	return Entry{
+		IP:      o.IP,
+		City:    o.Location.City,
+		Country: o.Location.Country,
+		ISOCode: o.Location.ISOCode,
+		Time:    o.Time,
+	}
+}
+
+// State is the last known entry of each address family, keyed by the family name.
+type State map[string]Entry
+
+// StateFile returns the path of the file that stores the last known addresses.
+func StateFile() (string, error) {
+	return statedir.File("last.json")
+}
+
+// Load returns the state stored in the named file.
+// A missing file returns an empty state.
+func Load(name string) (State, error) {
+	s := State{}
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("state file %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// Save writes the state to the named file, creating any missing parent directories.
+func (s State) Save(name string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), dirPerm); err != nil {
+		return err
+	}
+	return os.WriteFile(name, b, filePerm)
+}
+
+// Update stores the observations and returns an event for each one whose
+// IP address differs from the state. An observation of an unchanged address
+// with pending actions returns an event of the original change for those actions.
+// Observations without an IP address are ignored.
+func (s State) Update(obs ...watch.Observation) []Event {
+	events := []Event{}
+	for _, o := range obs {
+		if o.IP == "" {
+			continue
+		}
+		key := o.Family.String()
+		old := s[key]
+		s[key] = NewEntry(o)
+		if old.IP != o.IP {
+			events = append(events, Event{Old: old, New: o})
+			continue
+		}
+		if len(old.Pending) == 0 {
+			continue
+		}
+		e := Event{New: o, Pending: old.Pending}
+		if old.Prev != nil {
+			e.Old = *old.Prev
+		}
+		events = append(events, e)
+	}
+	for i := range events {
+		events[i].Old.Pending, events[i].Old.Prev = nil, nil
+	}
+	return events
+}
+
+// Defer keeps the failed actions of the event pending,
+// so the next Update returns the event again for only those actions.
+func (s State) Defer(e Event, failed ...string) {
+	if len(failed) == 0 {
+		return
+	}
+	key := e.New.Family.String()
+	entry := s[key]
+	prev := e.Old
+	entry.Pending, entry.Prev = failed, &prev
+	s[key] = entry
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/tmplfunc"
+)
+
+var (
+	ErrURL     = errors.New("webhook url is empty")
+	ErrWebhook = errors.New("unusual webhook server response")
+)
+
+const (
+	// SignatureHeader is the request header containing the HMAC-SHA256 signature of the body.
+	SignatureHeader = "X-Myip-Signature-256"
+	// Default duration between the webhook retries.
+	backoff = time.Second
+)
+
+// Payload is the JSON body of a webhook request, it is also the data passed to a body template.
+type Payload struct {
+	Family      string    `json:"family"`
+	Old         string    `json:"old"`
+	New         string    `json:"new"`
+	City        string    `json:"city,omitempty"`
+	Country     string    `json:"country,omitempty"`
+	CountryCode string    `json:"country_code,omitempty"`
+	OldCity     string    `json:"old_city,omitempty"`
+	OldCountry  string    `json:"old_country,omitempty"`
+	Providers   []string  `json:"providers"`
+	Time        time.Time `json:"time"`
+}
+
+// Payload returns the event as a webhook payload.
+func (e Event) Payload() Payload {
+	return Payload{
+		Family:      e.New.Family.String(),
+		Old:         e.Old.IP,
+		New:         e.New.IP,
+		City:        e.New.Location.City,
+		Country:     e.New.Location.Country,
+		CountryCode: e.New.Location.ISOCode,
+		OldCity:     e.Old.City,
+		OldCountry:  e.Old.Country,
+		Providers:   e.New.Providers,
+		Time:        e.New.Time,
+	}
+}
+
+// Webhook is a URL that is sent a HTTP POST request on an address change.
+type Webhook struct {
+	URL      string             // URL of the webhook.
+	HTTP     *http.Client       // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Secret   string             // Secret signs the body using HMAC-SHA256, an empty value sends no signature.
+	Template *template.Template // Template of the body, a nil value sends the JSON payload.
+	Type     string             // Type is the content type of the body, an empty value uses ContentType.
+	Retries  int                // Retries of a failed request.
+	Backoff  time.Duration      // Backoff is the duration between the first retry, it doubles on each retry.
+}
+
+// BodyTemplate parses the text as a template for a webhook body.
+// The template is passed a Payload and the tmplfunc helpers, such as the json function
+// that encodes a value, for example {"text": {{json (printf "IP address is now %s" .New)}}}.
+func BodyTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Funcs(tmplfunc.Map()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Body returns the request body of the event.
+func (w Webhook) Body(e Event) ([]byte, error) {
+	if w.Template == nil {
+		return json.Marshal(e.Payload())
+	}
+	var buf bytes.Buffer
+	if err := w.Template.Execute(&buf, e.Payload()); err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ContentType returns application/json when the body is valid JSON, otherwise text/plain.
+func ContentType(body []byte) string {
+	if json.Valid(body) {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the body prefixed with "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send the event to the webhook.
+// Requests that fail to connect or receive a 429 or 5xx status are retried,
+// other unsuccessful status codes return an error without a retry.
+func (w Webhook) Send(ctx context.Context, e Event) error {
+	if strings.TrimSpace(w.URL) == "" {
+		return ErrURL
+	}
+	body, err := w.Body(e)
+	if err != nil {
+		return err
+	}
+	wait := w.Backoff
+	if wait <= 0 {
+		wait = backoff
+	}
+	for i := 0; ; i++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || i >= w.Retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", w.URL, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// Post the body to the webhook and report whether a failed request can be retried.
+func (w Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	typ := w.Type
+	if typ == "" {
+		typ = ContentType(body)
+	}
+	req.Header.Set("Content-Type", typ)
+	req.Header.Set("User-Agent", "myip")
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+	client := w.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, fmt.Errorf("%s: %s, %w", w.URL, strings.ToLower(resp.Status), ErrWebhook)
+}
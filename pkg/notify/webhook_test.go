@@ -0,0 +1,143 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/notify"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+func TestWebhook_Send(t *testing.T) {
+	e := notify.Event{Old: notify.Entry{IP: "192.0.2.1"}, New: observe(provider.IPv4, "192.0.2.2")}
+	slack, err := notify.BodyTemplate(`{"text": {{json (printf "%s > %s, %s" .Old .New .Country)}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		tmpl     bool
+		secret   string
+		status   []int
+		retries  int
+		want     string
+		requests int32
+		wantErr  bool
+	}{
+		{"payload", false, "", []int{http.StatusOK}, 0, "", 1, false},
+		{"template", true, "", []int{http.StatusNoContent}, 0, `{"text": "192.0.2.1 > 192.0.2.2, Australia"}`, 1, false},
+		{"signed", false, "secret", []int{http.StatusOK}, 0, "", 1, false},
+		{"retry", false, "", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, 3, "", 3, false},
+		{"retries exceeded", false, "", []int{http.StatusBadGateway, http.StatusBadGateway}, 1, "", 2, true},
+		{"no retry", false, "", []int{http.StatusNotFound, http.StatusOK}, 3, "", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+				body, _ := io.ReadAll(r.Body)
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("request = %s %s, want a json post", r.Method, r.Header.Get("Content-Type"))
+				}
+				if sig := r.Header.Get(notify.SignatureHeader); tt.secret != "" && sig != notify.Sign(tt.secret, body) {
+					t.Errorf("signature = %q, want %q", sig, notify.Sign(tt.secret, body))
+				}
+				if tt.want != "" && string(body) != tt.want {
+					t.Errorf("body = %s, want %s", body, tt.want)
+				}
+				if !tt.tmpl {
+					var p notify.Payload
+					if err := json.Unmarshal(body, &p); err != nil || p.Old != "192.0.2.1" || p.New != "192.0.2.2" ||
+						p.Family != "ipv4" || p.City != "Sydney" || len(p.Providers) != 2 {
+						t.Errorf("payload = %s, %v", body, err)
+					}
+				}
+				w.WriteHeader(tt.status[n-1])
+			}))
+			defer srv.Close()
+			wh := notify.Webhook{
+				URL:     srv.URL,
+				Secret:  tt.secret,
+				Retries: tt.retries,
+				Backoff: time.Millisecond,
+			}
+			if tt.tmpl {
+				wh.Template = slack
+			}
+			err := wh.Send(context.Background(), e)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, notify.ErrWebhook) {
+				t.Errorf("Send() error = %v, want %v", err, notify.ErrWebhook)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("Send() requests = %d, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// https://en.wikipedia.org/wiki/HMAC#Examples
+	const want = "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if got := notify.Sign("key", []byte("The quick brown fox jumps over the lazy dog")); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"json", `{"text": "IP address changed"}`, "application/json"},
+		{"text", "IP address changed", "text/plain; charset=utf-8"},
+		{"form", "text=IP+address+changed", "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notify.ContentType([]byte(tt.body)); got != tt.want {
+				t.Errorf("ContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyTemplate(t *testing.T) {
+	e := notify.Event{Old: notify.Entry{IP: "192.0.2.1"}, New: observe(provider.IPv4, "192.0.2.2")}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"join", `{{join ", " .Providers}}`, "ipify, seeip"},
+		{"join pipe", `{{.Providers | join "+"}}`, "ipify+seeip"},
+		{"json", `{{json .New}}`, `"192.0.2.2"`},
+		{"upper", `{{upper .CountryCode}}`, "AU"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := notify.BodyTemplate(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := notify.Webhook{Template: tmpl}.Body(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Body() = %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
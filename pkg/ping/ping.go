@@ -6,14 +6,19 @@ package ping
 import (
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/provider"
 )
 
 var ErrInvalid = errors.New("invalid ip address")
 
 const (
-	Zero  = "(0/4) " // Zero returns a pre-ping string.
+	// Zero returns a pre-ping string for the four original providers.
+	//
+	// Deprecated: Use Progress with the number of requests to make.
+	Zero  = "(0/4) "
 	Zero1 = "(0/1) " // Zero1 returns a pre-ping string for the first flag.
 )
 
@@ -57,26 +62,74 @@ func Sprint(ip string) string {
 	return "\r(1/1) " + city
 }
 
-// Sprint returns a formatted IP address for the All requests.
-// The completed value is displayed as the number of finished requests.
+// Sprints returns a formatted IP address for the All requests.
+// The completed value is displayed as the number of finished requests
+// out of the number of providers in the default registry.
 // Enabling raw returns the IP address without any city or country information.
+//
+// Deprecated: Use SprintsN with the number of requests made, such as the resolver Len.
 func Sprints(ip string, completed int, raw bool) string {
+	return SprintsN(ip, completed, provider.Len(), raw)
+}
+
+// SprintsN returns a formatted IP address for the All requests.
+// The completed value is displayed as the number of finished requests
+// out of the total, such as the resolver Len.
+// Enabling raw returns the IP address without any city or country information.
+func SprintsN(ip string, completed, total int, raw bool) string {
 	if ip == "" {
 		return ""
 	}
 	if raw {
-		return count(completed, ip)
+		return Progress(completed, total, ip)
 	}
 	s, err := City(ip)
 	if err != nil {
-		return fmt.Sprintf("%s, %s", count(completed, ip), err)
+		return fmt.Sprintf("%s, %s", Progress(completed, total, ip), err)
+	}
+	return Progress(completed, total, s)
+}
+
+// Fprint writes the results to w as the replies come in and returns them.
+// The total is the number of requests made, such as the resolver Len.
+// Repeated IP addresses are written on the same line.
+// Enabling raw writes the IP addresses without any city or country information.
+func Fprint(w io.Writer, c <-chan provider.Result, total int, raw bool) []provider.Result {
+	complete, ips, results := 0, []string{}, []provider.Result{}
+	for r := range c {
+		results = append(results, r)
+		complete++
+		if r.Err != nil {
+			s := Progress(complete, total, r.Err.Error())
+			if complete == 1 {
+				fmt.Fprint(w, s)
+			} else {
+				fmt.Fprintf(w, "\n%s", s)
+			}
+			continue
+		}
+		if r.IP == "" {
+			continue
+		}
+		s := Progress(complete, total, r.String())
+		if raw {
+			s = Progress(complete, total, r.IP)
+		}
+		newIP := !Contains(ips, r.IP)
+		if newIP {
+			ips = append(ips, r.IP)
+		}
+		if newIP && len(ips) > 1 {
+			fmt.Fprintf(w, "\n%s", s)
+		} else {
+			fmt.Fprint(w, s)
+		}
 	}
-	return count(completed, s)
+	return results
 }
 
-// Count returns a formatted job count and IP address.
-func count(completed int, s string) string {
-	const total = 4
+// Progress returns a formatted count of the completed requests with the string.
+func Progress(completed, total int, s string) string {
 	// (1/4) 93.184.216.34, Norwell, United States
 	return fmt.Sprintf("\r(%d/%d) %s", completed, total, s)
 }
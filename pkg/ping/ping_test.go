@@ -1,10 +1,13 @@
 package ping_test
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/bengarrett/myip/pkg/ping"
+	"github.com/bengarrett/myip/pkg/provider"
 )
 
 const (
@@ -87,10 +90,11 @@ func TestSprint(t *testing.T) {
 	}
 }
 
-func TestSprints(t *testing.T) {
+func TestSprintsN(t *testing.T) {
 	type args struct {
 		ip        string
 		completed int
+		total     int
 		raw       bool
 	}
 	tests := []struct {
@@ -99,16 +103,58 @@ func TestSprints(t *testing.T) {
 		want string
 	}{
 		{"empty", args{}, ""},
-		{"invalid", args{"a.b.c.d", 1, false}, "(1/4) a.b.c.d, invalid ip address"},
-		{"invalid raw", args{"a.b.c.d", 1, true}, "(1/4) a.b.c.d"},
-		{"no geo-location", args{"0.0.0.0", 2, false}, "(2/4) 0.0.0.0"},
-		{"example", args{example, 4, false}, "(4/4) " + norwell},
-		{"example raw", args{example, 4, true}, "(4/4) " + example},
+		{"invalid", args{"a.b.c.d", 1, 4, false}, "(1/4) a.b.c.d, invalid ip address"},
+		{"invalid raw", args{"a.b.c.d", 1, 4, true}, "(1/4) a.b.c.d"},
+		{"no geo-location", args{"0.0.0.0", 2, 4, false}, "(2/4) 0.0.0.0"},
+		{"example", args{example, 4, 4, false}, "(4/4) " + norwell},
+		{"example raw", args{example, 4, 4, true}, "(4/4) " + example},
+		{"total raw", args{example, 3, 7, true}, "(3/7) " + example},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.TrimSpace(ping.SprintsN(tt.args.ip, tt.args.completed, tt.args.total, tt.args.raw)); got != tt.want {
+				t.Errorf("SprintsN() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSprints(t *testing.T) {
+	want := fmt.Sprintf("(1/%d) %s", provider.Len(), example)
+	if got := strings.TrimSpace(ping.Sprints(example, 1, true)); got != want {
+		t.Errorf("Sprints() = %v, want %v", got, want)
+	}
+}
+
+func TestFprint(t *testing.T) {
+	results := []provider.Result{
+		{Provider: "a", IP: "192.0.2.1"},
+		{Provider: "b", Err: errors.New("timeout")},
+		{Provider: "c", IP: "192.0.2.1"},
+		{Provider: "d", IP: "192.0.2.2"},
+	}
+	tests := []struct {
+		name  string
+		total int
+		want  string
+	}{
+		{"resolver total", 4, "\r(1/4) 192.0.2.1\n\r(2/4) timeout\r(3/4) 192.0.2.1\n\r(4/4) 192.0.2.2"},
+		{"skipped providers", 7, "\r(1/7) 192.0.2.1\n\r(2/7) timeout\r(3/7) 192.0.2.1\n\r(4/7) 192.0.2.2"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := strings.TrimSpace(ping.Sprints(tt.args.ip, tt.args.completed, tt.args.raw)); got != tt.want {
-				t.Errorf("Sprints() = %v, want %v", got, tt.want)
+			c := make(chan provider.Result, len(results))
+			for _, r := range results {
+				c <- r
+			}
+			close(c)
+			var sb strings.Builder
+			got := ping.Fprint(&sb, c, tt.total, true)
+			if sb.String() != tt.want {
+				t.Errorf("Fprint() wrote %q, want %q", sb.String(), tt.want)
+			}
+			if len(got) != len(results) {
+				t.Errorf("Fprint() = %d results, want %d", len(got), len(results))
 			}
 		})
 	}
@@ -0,0 +1,180 @@
+// Package plaintext returns your Internet-facing IPv4 or IPv6 address,
+// sourced from any online API that replies with the address as plain text.
+// It is shared by the ipify API and the plain-text echo services.
+// © Ben Garrett https://github.com/bengarrett/myip
+package plaintext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+// https://icanhazip.com
+//
+// Output:
+// 1.1.1.1
+
+var (
+	ErrNoIP    = errors.New("ip address is empty")
+	ErrNoIPv4  = errors.New("ip address is not ipv4")
+	ErrNoIPv6  = errors.New("ip address is not ipv6")
+	ErrInvalid = errors.New("ip address is invalid")
+	ErrStatus  = errors.New("unusual server response")
+	ErrFamily  = errors.New("service does not support the address family")
+)
+
+// Client requests a plain-text API using a HTTP client and endpoint URLs.
+type Client struct {
+	HTTP   *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	HTTPv6 *http.Client // HTTPv6 is the HTTP client for the IPv6 requests, a nil value uses HTTP.
+	Domain string       // Domain of the service that is used in the errors.
+	Linkv4 string       // Linkv4 is the IPv4 endpoint URL, an empty value is unsupported.
+	Linkv6 string       // Linkv6 is the IPv6 endpoint URL, an empty value is unsupported.
+}
+
+func (c Client) client() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
+
+// Dial returns a copy of the HTTP client that only connects using the network,
+// either tcp4 or tcp6. It is used by the dual-stack endpoints that reply with
+// the address of the connection. A client that does not use a *http.Transport
+// is returned unchanged.
+func Dial(c *http.Client, network string) *http.Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	rt := c.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return c
+	}
+	t = t.Clone()
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dial(ctx, network, addr)
+	}
+	x := *c
+	x.Transport = t
+	return &x
+}
+
+// URLs returns the IPv4 and IPv6 endpoint URLs used by the client.
+func (c Client) URLs() (string, string) {
+	return c.Linkv4, c.Linkv6
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return c.family(ctx, cancel, c.Linkv4, false)
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	return c.family(ctx, cancel, c.Linkv6, true)
+}
+
+func (c Client) family(ctx context.Context, cancel context.CancelFunc, url string, ipv6 bool) (string, error) {
+	if url == "" {
+		defer cancel()
+		return "", fault.New(c.Domain, ErrFamily)
+	}
+	if ipv6 && c.HTTPv6 != nil {
+		c.HTTP = c.HTTPv6
+	}
+	ip, err := c.Request(ctx, cancel, url)
+	if err != nil {
+		return ip, err
+	}
+	if err := Family(ipv6, ip); err != nil {
+		return ip, fault.Wrap(c.Domain, fault.Validation, err)
+	}
+	return ip, nil
+}
+
+// Request the API and return a valid IPv4 or IPv6 address.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	b, err := c.RequestB(ctx, cancel, url)
+	if err != nil {
+		return "", fault.New(c.Domain, err)
+	}
+
+	ip := strings.TrimSpace(string(b))
+	if err := Valid(ip); err != nil {
+		return ip, fault.Wrap(c.Domain, fault.Validation, err)
+	}
+
+	return ip, nil
+}
+
+// RequestB requests the API and return the response body.
+func (c Client) RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byte, error) {
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fault.Wrap(c.Domain, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return b, fault.Wrap(c.Domain, fault.Parse, err)
+	}
+
+	return b, nil
+}
+
+// Valid returns nil if ip is a valid textual representation of an IP address.
+func Valid(ip string) error {
+	if ip == "" {
+		return ErrNoIP
+	}
+	if net.ParseIP(ip) == nil {
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+// Family returns nil if ip is a valid textual representation of an IP address of the family.
+func Family(ipv6 bool, ip string) error {
+	if err := Valid(ip); err != nil {
+		return err
+	}
+	v4 := net.ParseIP(ip).To4() != nil
+	switch {
+	case ipv6 && v4:
+		return ErrNoIPv6
+	case !ipv6 && !v4:
+		return ErrNoIPv4
+	}
+
+	return nil
+}
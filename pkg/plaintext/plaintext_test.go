@@ -0,0 +1,118 @@
+package plaintext_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/plaintext"
+)
+
+func TestTimeout(t *testing.T) {
+	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
+	if _, err := plaintext.ICanHazIP(nil).IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
+	}
+}
+
+func TestFamily(t *testing.T) {
+	tests := []struct {
+		name string
+		ipv6 bool
+		ip   string
+		want error
+	}{
+		{"ipv4", false, myiptest.IPv4, nil},
+		{"ipv6", true, myiptest.IPv6, nil},
+		{"empty", false, "", plaintext.ErrNoIP},
+		{"invalid", true, "abc", plaintext.ErrInvalid},
+		{"not ipv4", false, myiptest.IPv6, plaintext.ErrNoIPv4},
+		{"not ipv6", true, myiptest.IPv4, plaintext.ErrNoIPv6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := plaintext.Family(tt.ipv6, tt.ip); !errors.Is(err, tt.want) {
+				t.Errorf("Family() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.OK, true, myiptest.IPv6, nil},
+		{"wrong family", myiptest.WrongFamily, true, "", plaintext.ErrNoIPv6},
+		{"malformed", myiptest.Malformed, false, "", plaintext.ErrInvalid},
+		{"status", myiptest.Status, false, "", plaintext.ErrStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.Ipify, tt.behavior)
+			defer srv.Close()
+			c := plaintext.Client{HTTP: srv.Client(), Domain: "example.com", Linkv4: srv.Linkv4, Linkv6: srv.Linkv6}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if _, err := plaintext.AWSCheckIP(nil).IPv6(ctx, cancel); !errors.Is(err, plaintext.ErrFamily) {
+		t.Errorf("AWSCheckIP() IPv6() error = %v, want %v", err, plaintext.ErrFamily)
+	}
+}
+
+func TestClient_Request(t *testing.T) {
+	// icanhazip and most other echo services end the address with a newline
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, myiptest.IPv4)
+	}))
+	defer srv.Close()
+	c := plaintext.Client{HTTP: srv.Client(), Domain: "example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if s, err := c.Request(ctx, cancel, srv.URL); err != nil || s != myiptest.IPv4 {
+		t.Errorf("Request() = %q, %v, want %q", s, err, myiptest.IPv4)
+	}
+}
+
+func TestDial(t *testing.T) {
+	// the server replies with the address of the connection, like ifconfig.me
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		fmt.Fprintln(w, host)
+	}))
+	defer srv.Close()
+	c := plaintext.Ifconfig(srv.Client())
+	c.Linkv4, c.Linkv6 = srv.URL, srv.URL
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if s, err := c.IPv4(ctx, cancel); err != nil || s != "127.0.0.1" {
+		t.Errorf("IPv4() = %v, %v, want 127.0.0.1", s, err)
+	}
+	// the server only listens on ipv4, so a tcp6 dial must fail to connect
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	if _, err := c.IPv6(ctx, cancel); err == nil || errors.Is(err, plaintext.ErrNoIPv6) {
+		t.Errorf("IPv6() error = %v, want a dial error", err)
+	}
+}
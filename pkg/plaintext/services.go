@@ -0,0 +1,44 @@
+package plaintext
+
+import "net/http"
+
+// The plain-text echo services.
+const (
+	ICanHazIPv4  = "https://ipv4.icanhazip.com"    // ICanHazIPv4 is the icanhazip IPv4 endpoint.
+	ICanHazIPv6  = "https://ipv6.icanhazip.com"    // ICanHazIPv6 is the icanhazip IPv6 endpoint.
+	IfconfigMe   = "https://ifconfig.me/ip"        // IfconfigMe is the dual-stack ifconfig.me endpoint.
+	IdentMev4    = "https://v4.ident.me"           // IdentMev4 is the ident.me IPv4 endpoint.
+	IdentMev6    = "https://v6.ident.me"           // IdentMev6 is the ident.me IPv6 endpoint.
+	AWSCheckIPv4 = "https://checkip.amazonaws.com" // AWSCheckIPv4 is the AWS checkip endpoint, it is IPv4 only.
+)
+
+// ICanHazIP returns a client of the icanhazip service using the HTTP client.
+// https://icanhazip.com
+func ICanHazIP(c *http.Client) Client {
+	return Client{HTTP: c, Domain: "icanhazip.com", Linkv4: ICanHazIPv4, Linkv6: ICanHazIPv6}
+}
+
+// Ifconfig returns a client of the ifconfig.me service using the HTTP client.
+// The service uses a single endpoint that replies with the address of the connection,
+// so the IPv4 requests only dial tcp4 and the IPv6 requests only dial tcp6.
+// Otherwise on a dual-stack network, either request could return the address of the other family.
+// https://ifconfig.me
+func Ifconfig(c *http.Client) Client {
+	return Client{
+		HTTP: Dial(c, "tcp4"), HTTPv6: Dial(c, "tcp6"),
+		Domain: "ifconfig.me", Linkv4: IfconfigMe, Linkv6: IfconfigMe,
+	}
+}
+
+// IdentMe returns a client of the ident.me service using the HTTP client.
+// https://ident.me
+func IdentMe(c *http.Client) Client {
+	return Client{HTTP: c, Domain: "ident.me", Linkv4: IdentMev4, Linkv6: IdentMev6}
+}
+
+// AWSCheckIP returns a client of the Amazon Web Services checkip service using the HTTP client.
+// The service does not support IPv6.
+// https://checkip.amazonaws.com
+func AWSCheckIP(c *http.Client) Client {
+	return Client{HTTP: c, Domain: "checkip.amazonaws.com", Linkv4: AWSCheckIPv4}
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/bengarrett/myip/pkg/cftrace"
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/ipinfo"
+	"github.com/bengarrett/myip/pkg/myipcom"
+)
+
+// Keys of the attributes reported by the providers.
+const (
+	AttrASN         = "asn"          // AttrASN is the autonomous system number, for example AS15133.
+	AttrCity        = "city"         // AttrCity is the city name.
+	AttrColo        = "colo"         // AttrColo is the IATA airport code of the data center that replied.
+	AttrCountry     = "country"      // AttrCountry is the country name.
+	AttrCountryCode = "country_code" // AttrCountryCode is the ISO 3166-1 country code.
+	AttrHostname    = "hostname"     // AttrHostname is the reverse DNS hostname.
+	AttrLoc         = "loc"          // AttrLoc is the latitude and longitude.
+	AttrOrg         = "org"          // AttrOrg is the organisation that holds the address.
+	AttrPostal      = "postal"       // AttrPostal is the postal code.
+	AttrRegion      = "region"       // AttrRegion is the region or state name.
+	AttrTimezone    = "timezone"     // AttrTimezone is the IANA time zone name.
+	AttrTLS         = "tls"          // AttrTLS is the TLS version of the connection.
+	AttrWARP        = "warp"         // AttrWARP is the Cloudflare WARP status, either off, on or plus.
+)
+
+// Attributes are the details of an IP address reported by a provider, keyed by the Attr names.
+type Attributes map[string]string
+
+// Location returns the city and country attributes as a location.
+func (a Attributes) Location() geolite2.Location {
+	return geolite2.Location{City: a[AttrCity], Country: a[AttrCountry], ISOCode: a[AttrCountryCode]}
+}
+
+// Detail is the function signature used by the provider packages to return
+// an IP address with the attributes that the provider reports.
+type Detail func(ctx context.Context, cancel context.CancelFunc) (string, Attributes, error)
+
+// Describer is a Provider that also reports attributes of the IP address,
+// such as its organisation or location.
+type Describer interface {
+	Provider
+	// Describe requests the provider and returns a valid IP address of
+	// the address family with the attributes reported by the provider.
+	Describe(ctx context.Context, cancel context.CancelFunc, f Family) (string, Attributes, error)
+}
+
+// detail returns a Detail using the request of a provider package and
+// a function that returns the IP address and attributes of the reply.
+func detail[T any](request func(context.Context, context.CancelFunc) (T, error), attrs func(T) (string, Attributes),
+) Detail {
+	return func(ctx context.Context, cancel context.CancelFunc) (string, Attributes, error) {
+		r, err := request(ctx, cancel)
+		ip, a := attrs(r)
+		return ip, a, err
+	}
+}
+
+// compact removes the attributes with empty values.
+func compact(a Attributes) Attributes {
+	for k, v := range a {
+		if v == "" {
+			delete(a, k)
+		}
+	}
+	return a
+}
+
+func cftraceAttrs(t cftrace.Trace) (string, Attributes) {
+	return t.IP, compact(Attributes{
+		AttrCountryCode: t.Loc,
+		AttrColo:        t.Colo,
+		AttrTLS:         t.TLS,
+		AttrWARP:        t.Warp,
+	})
+}
+
+func ipinfoAttrs(r ipinfo.Result) (string, Attributes) {
+	return r.IP, compact(Attributes{
+		AttrASN:         r.ASN(),
+		AttrCity:        r.City,
+		AttrCountryCode: r.Country,
+		AttrHostname:    r.Hostname,
+		AttrLoc:         r.Loc,
+		AttrOrg:         r.Organisation(),
+		AttrPostal:      r.Postal,
+		AttrRegion:      r.Region,
+		AttrTimezone:    r.Timezone,
+	})
+}
+
+func myipcomAttrs(r myipcom.Result) (string, Attributes) {
+	return r.IP, compact(Attributes{
+		AttrCountry:     r.Country,
+		AttrCountryCode: r.ISOCode,
+	})
+}
@@ -0,0 +1,344 @@
+// Package provider defines the online services that report
+// your Internet-facing IP address and a registry to hold them.
+// © Ben Garrett https://github.com/bengarrett/myip
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/bengarrett/myip/pkg/cftrace"
+	"github.com/bengarrett/myip/pkg/dnsip"
+	"github.com/bengarrett/myip/pkg/ipify"
+	"github.com/bengarrett/myip/pkg/ipinfo"
+	"github.com/bengarrett/myip/pkg/myipcom"
+	"github.com/bengarrett/myip/pkg/myipio"
+	"github.com/bengarrett/myip/pkg/plaintext"
+	"github.com/bengarrett/myip/pkg/seeip"
+	"github.com/bengarrett/myip/pkg/stun"
+)
+
+var (
+	ErrDuplicate = errors.New("provider is already registered")
+	ErrFamily    = errors.New("provider does not support the address family")
+	ErrName      = errors.New("provider name is empty")
+)
+
+// Family is an Internet Protocol address family.
+type Family uint8
+
+const (
+	IPv4 Family = 1 << iota // IPv4 is the Internet Protocol version 4 address family.
+	IPv6                    // IPv6 is the Internet Protocol version 6 address family.
+
+	Both = IPv4 | IPv6 // Both is a dual-stack request of the IPv4 and IPv6 families.
+)
+
+func (f Family) String() string {
+	switch f {
+	case IPv4:
+		return "ipv4"
+	case IPv6:
+		return "ipv6"
+	case Both:
+		return "both"
+	}
+	return fmt.Sprintf("family(%d)", uint8(f))
+}
+
+// Families returns the individual address families contained in f.
+func (f Family) Families() []Family {
+	fams := []Family{}
+	for _, x := range []Family{IPv4, IPv6} {
+		if f&x != 0 {
+			fams = append(fams, x)
+		}
+	}
+	return fams
+}
+
+// Provider is an online service that returns the Internet-facing IP address of the client.
+type Provider interface {
+	// Name returns a short, unique name of the provider.
+	Name() string
+	// Endpoint returns the URL requested for the address family,
+	// or an empty string when the family is unsupported.
+	Endpoint(f Family) string
+	// Request the provider and return a valid IP address of the address family.
+	Request(ctx context.Context, cancel context.CancelFunc, f Family) (string, error)
+}
+
+// Request is the function signature used by the provider packages to return an IP address.
+type Request func(ctx context.Context, cancel context.CancelFunc) (string, error)
+
+// Service is a Provider built from the request functions of a provider package.
+type Service struct {
+	ID       string  // ID is the unique name of the service.
+	Linkv4   string  // Linkv4 is the URL used for IPv4 requests.
+	Linkv6   string  // Linkv6 is the URL used for IPv6 requests.
+	IPv4     Request // IPv4 requests an IPv4 address, a nil value is unsupported.
+	IPv6     Request // IPv6 requests an IPv6 address, a nil value is unsupported.
+	Detailv4 Detail  // Detailv4 requests an IPv4 address with its attributes, a nil value uses IPv4.
+	Detailv6 Detail  // Detailv6 requests an IPv6 address with its attributes, a nil value uses IPv6.
+}
+
+// Name returns the unique name of the service.
+func (s Service) Name() string {
+	return s.ID
+}
+
+// Endpoint returns the URL requested for the address family.
+func (s Service) Endpoint(f Family) string {
+	switch f {
+	case IPv4:
+		return s.Linkv4
+	case IPv6:
+		return s.Linkv6
+	}
+	return ""
+}
+
+// Request the service and return a valid IP address of the address family.
+func (s Service) Request(ctx context.Context, cancel context.CancelFunc, f Family) (string, error) {
+	switch {
+	case f == IPv4 && s.IPv4 != nil:
+		return s.IPv4(ctx, cancel)
+	case f == IPv6 && s.IPv6 != nil:
+		return s.IPv6(ctx, cancel)
+	}
+	defer cancel()
+	return "", fmt.Errorf("%s %s: %w", s.ID, f, ErrFamily)
+}
+
+// Describe requests the service and returns a valid IP address of the address family
+// with its attributes. A service without a detail request returns nil attributes.
+func (s Service) Describe(ctx context.Context, cancel context.CancelFunc, f Family) (string, Attributes, error) {
+	switch {
+	case f == IPv4 && s.Detailv4 != nil:
+		return s.Detailv4(ctx, cancel)
+	case f == IPv6 && s.Detailv6 != nil:
+		return s.Detailv6(ctx, cancel)
+	}
+	ip, err := s.Request(ctx, cancel, f)
+	return ip, nil, err
+}
+
+// Ipify returns the ipify API provider using the client.
+func Ipify(c ipify.Client) Service {
+	v4, v6 := c.URLs()
+	return Service{ID: "ipify", Linkv4: v4, Linkv6: v6, IPv4: c.IPv4, IPv6: c.IPv6}
+}
+
+// MyIPcom returns the MYIP.com API provider using the client.
+// It reports the country attributes of the address.
+func MyIPcom(c myipcom.Client) Service {
+	return Service{
+		ID: "myipcom", Linkv4: c.URL(), Linkv6: c.URL(), IPv4: c.IPv4, IPv6: c.IPv6,
+		Detailv4: detail(c.ResultV4, myipcomAttrs), Detailv6: detail(c.ResultV6, myipcomAttrs),
+	}
+}
+
+// MyIPio returns the Workshell MyIP API provider using the client.
+func MyIPio(c myipio.Client) Service {
+	v4, v6 := c.URLs()
+	return Service{ID: "myipio", Linkv4: v4, Linkv6: v6, IPv4: c.IPv4, IPv6: c.IPv6}
+}
+
+// SeeIP returns the SeeIP API provider using the client.
+func SeeIP(c seeip.Client) Service {
+	v4, v6 := c.URLs()
+	return Service{ID: "seeip", Linkv4: v4, Linkv6: v6, IPv4: c.IPv4, IPv6: c.IPv6}
+}
+
+// CFTrace returns the Cloudflare trace provider using the client.
+// It reports the country code, data center, TLS and WARP attributes of the connection.
+func CFTrace(c cftrace.Client) Service {
+	v4, v6 := c.URLs()
+	return Service{
+		ID: "cftrace", Linkv4: v4, Linkv6: v6, IPv4: c.IPv4, IPv6: c.IPv6,
+		Detailv4: detail(c.TraceV4, cftraceAttrs), Detailv6: detail(c.TraceV6, cftraceAttrs),
+	}
+}
+
+// IPinfo returns the IPinfo API provider using the client.
+// It reports the hostname, location, organisation and ASN attributes of the address.
+func IPinfo(c ipinfo.Client) Service {
+	v4, v6 := c.URLs()
+	return Service{
+		ID: "ipinfo", Linkv4: v4, Linkv6: v6, IPv4: c.IPv4, IPv6: c.IPv6,
+		Detailv4: detail(c.ResultV4, ipinfoAttrs), Detailv6: detail(c.ResultV6, ipinfoAttrs),
+	}
+}
+
+// PlainText returns a plain-text echo service provider using the client.
+// An empty endpoint URL of the client is an unsupported address family.
+func PlainText(id string, c plaintext.Client) Service {
+	s := Service{ID: id, Linkv4: c.Linkv4, Linkv6: c.Linkv6}
+	if c.Linkv4 != "" {
+		s.IPv4 = c.IPv4
+	}
+	if c.Linkv6 != "" {
+		s.IPv6 = c.IPv6
+	}
+	return s
+}
+
+// DNS returns a DNS echo service provider using the client.
+// An empty query link of the client is an unsupported address family.
+func DNS(id string, c dnsip.Client) Service {
+	s := Service{ID: id, Linkv4: c.Linkv4, Linkv6: c.Linkv6}
+	if c.Linkv4 != "" {
+		s.IPv4 = c.IPv4
+	}
+	if c.Linkv6 != "" {
+		s.IPv6 = c.IPv6
+	}
+	return s
+}
+
+// STUN returns the STUN provider using the client.
+func STUN(c stun.Client) Service {
+	return Service{ID: "stun", Linkv4: c.URL(), Linkv6: c.URL(), IPv4: c.IPv4, IPv6: c.IPv6}
+}
+
+// Builtin returns the built-in providers using the HTTP client and their default endpoints.
+// A nil client uses http.DefaultClient.
+func Builtin(c *http.Client) []Provider {
+	return []Provider{
+		Ipify(ipify.Client{HTTP: c}),
+		MyIPcom(myipcom.Client{HTTP: c}),
+		MyIPio(myipio.Client{HTTP: c}),
+		SeeIP(seeip.Client{HTTP: c}),
+		PlainText("icanhazip", plaintext.ICanHazIP(c)),
+		PlainText("ifconfig", plaintext.Ifconfig(c)),
+		PlainText("identme", plaintext.IdentMe(c)),
+		PlainText("awscheckip", plaintext.AWSCheckIP(c)),
+	}
+}
+
+// Available returns the built-in providers followed by the optional providers,
+// which are not used by default, using the HTTP client and their default endpoints.
+// A nil client uses http.DefaultClient, the DNS and STUN providers do not use the client.
+func Available(c *http.Client) []Provider {
+	return append(Builtin(c),
+		CFTrace(cftrace.Client{HTTP: c}),
+		IPinfo(ipinfo.Client{HTTP: c}),
+		DNS("opendns", dnsip.OpenDNS()),
+		DNS("googledns", dnsip.Google()),
+		DNS("cloudflaredns", dnsip.Cloudflare()),
+		STUN(stun.Client{}),
+	)
+}
+
+// Registry is an ordered collection of uniquely named providers
+// that is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	providers []Provider
+}
+
+// NewRegistry returns a registry containing the providers.
+// Any provider with an empty or duplicate name is skipped.
+func NewRegistry(p ...Provider) *Registry {
+	r := &Registry{}
+	for _, x := range p {
+		_ = r.Register(x)
+	}
+	return r
+}
+
+// Register appends the provider to the registry.
+func (r *Registry) Register(p Provider) error {
+	name := p.Name()
+	if name == "" {
+		return ErrName
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, x := range r.providers {
+		if x.Name() == name {
+			return fmt.Errorf("%s: %w", name, ErrDuplicate)
+		}
+	}
+	r.providers = append(r.providers, p)
+	return nil
+}
+
+// Unregister removes the named provider from the registry
+// and reports whether it was found.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, x := range r.providers {
+		if x.Name() == name {
+			r.providers = append(r.providers[:i], r.providers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the named provider.
+func (r *Registry) Lookup(name string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, x := range r.providers {
+		if x.Name() == name {
+			return x, true
+		}
+	}
+	return nil, false
+}
+
+// Providers returns a copy of the registered providers in the order they were added.
+func (r *Registry) Providers() []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p := make([]Provider, len(r.providers))
+	copy(p, r.providers)
+	return p
+}
+
+// Names returns the names of the registered providers in the order they were added.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.providers))
+	for _, x := range r.providers {
+		names = append(names, x.Name())
+	}
+	return names
+}
+
+// Len returns the number of registered providers.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.providers)
+}
+
+// Default is the registry of providers used by the ipv4 and ipv6 packages.
+var Default = NewRegistry(Builtin(nil)...) //nolint: gochecknoglobals
+
+// Register appends the provider to the default registry.
+func Register(p Provider) error {
+	return Default.Register(p)
+}
+
+// Unregister removes the named provider from the default registry.
+func Unregister(name string) bool {
+	return Default.Unregister(name)
+}
+
+// Providers returns the providers in the default registry.
+func Providers() []Provider {
+	return Default.Providers()
+}
+
+// Len returns the number of providers in the default registry.
+func Len() int {
+	return Default.Len()
+}
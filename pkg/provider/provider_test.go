@@ -0,0 +1,155 @@
+package provider_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/plaintext"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+type fake struct {
+	name string
+	ip   string
+}
+
+func (f fake) Name() string                      { return f.name }
+func (f fake) Endpoint(_ provider.Family) string { return "http://localhost" }
+func (f fake) Request(_ context.Context, cancel context.CancelFunc, _ provider.Family) (string, error) {
+	defer cancel()
+	return f.ip, nil
+}
+
+func TestDefault(t *testing.T) {
+	want := []string{"ipify", "myipcom", "myipio", "seeip", "icanhazip", "ifconfig", "identme", "awscheckip"}
+	got := provider.Providers()
+	if len(got) != len(want) {
+		t.Fatalf("Providers() = %d providers, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if p.Name() != want[i] {
+			t.Errorf("Providers()[%d] = %v, want %v", i, p.Name(), want[i])
+		}
+		if p.Endpoint(provider.IPv4) == "" {
+			t.Errorf("%s Endpoint(IPv4) is empty", p.Name())
+		}
+	}
+	if got := provider.Len(); got != len(want) {
+		t.Errorf("Len() = %d, want %d", got, len(want))
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	want := []string{
+		"ipify", "myipcom", "myipio", "seeip", "icanhazip", "ifconfig", "identme", "awscheckip",
+		"cftrace", "ipinfo", "opendns", "googledns", "cloudflaredns", "stun",
+	}
+	got := provider.Available(nil)
+	if len(got) != len(want) {
+		t.Fatalf("Available() = %d providers, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if p.Name() != want[i] {
+			t.Errorf("Available()[%d] = %v, want %v", i, p.Name(), want[i])
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := provider.NewRegistry(fake{"a", "1.1.1.1"}, fake{"b", "1.1.1.2"}, fake{"a", "1.1.1.3"})
+	if got := r.Len(); got != 2 {
+		t.Errorf("NewRegistry() Len() = %d, want 2", got)
+	}
+	if err := r.Register(fake{"b", ""}); !errors.Is(err, provider.ErrDuplicate) {
+		t.Errorf("Register() error = %v, want %v", err, provider.ErrDuplicate)
+	}
+	if err := r.Register(fake{"", ""}); !errors.Is(err, provider.ErrName) {
+		t.Errorf("Register() error = %v, want %v", err, provider.ErrName)
+	}
+	if err := r.Register(fake{"c", "1.1.1.4"}); err != nil {
+		t.Errorf("Register() error = %v, want nil", err)
+	}
+	if ok := r.Unregister("a"); !ok {
+		t.Errorf("Unregister() = %v, want true", ok)
+	}
+	if ok := r.Unregister("a"); ok {
+		t.Errorf("Unregister() = %v, want false", ok)
+	}
+	if _, ok := r.Lookup("c"); !ok {
+		t.Errorf("Lookup() = %v, want true", ok)
+	}
+	names := ""
+	for _, p := range r.Providers() {
+		names += p.Name()
+	}
+	if names != "bc" {
+		t.Errorf("Providers() = %q, want %q", names, "bc")
+	}
+	if got := strings.Join(r.Names(), ""); got != "bc" {
+		t.Errorf("Names() = %q, want %q", got, "bc")
+	}
+}
+
+func TestService(t *testing.T) {
+	s := provider.Service{ID: "v4only", Linkv4: "http://localhost", IPv4: func(_ context.Context, cancel context.CancelFunc) (string, error) {
+		defer cancel()
+		return "1.1.1.1", nil
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	if got, err := s.Request(ctx, cancel, provider.IPv4); err != nil || got != "1.1.1.1" {
+		t.Errorf("Request(IPv4) = %v, %v, want 1.1.1.1", got, err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	if _, err := s.Request(ctx, cancel, provider.IPv6); !errors.Is(err, provider.ErrFamily) {
+		t.Errorf("Request(IPv6) error = %v, want %v", err, provider.ErrFamily)
+	}
+	if got := s.Endpoint(provider.IPv6); got != "" {
+		t.Errorf("Endpoint(IPv6) = %q, want empty", got)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	s := provider.PlainText("awscheckip", plaintext.AWSCheckIP(nil))
+	if s.Endpoint(provider.IPv4) != plaintext.AWSCheckIPv4 || s.IPv6 != nil {
+		t.Errorf("PlainText() = %+v, want an IPv4 only provider", s)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := s.Request(ctx, cancel, provider.IPv6); !errors.Is(err, provider.ErrFamily) {
+		t.Errorf("Request(IPv6) error = %v, want %v", err, provider.ErrFamily)
+	}
+}
+
+func TestService_Describe(t *testing.T) {
+	s := provider.Service{ID: "detail", Linkv4: "http://localhost",
+		IPv4: func(_ context.Context, cancel context.CancelFunc) (string, error) {
+			defer cancel()
+			return "1.1.1.1", nil
+		},
+		Detailv6: func(_ context.Context, cancel context.CancelFunc) (string, provider.Attributes, error) {
+			defer cancel()
+			return "2606:4700:4700::1111", provider.Attributes{provider.AttrASN: "AS13335"}, nil
+		},
+	}
+	var _ provider.Describer = s
+	ctx, cancel := context.WithCancel(context.Background())
+	if got, attrs, err := s.Describe(ctx, cancel, provider.IPv4); err != nil || got != "1.1.1.1" || attrs != nil {
+		t.Errorf("Describe(IPv4) = %v, %v, %v, want 1.1.1.1 without attributes", got, attrs, err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	if _, attrs, err := s.Describe(ctx, cancel, provider.IPv6); err != nil || attrs[provider.AttrASN] != "AS13335" {
+		t.Errorf("Describe(IPv6) = %v, %v, want the asn attribute", attrs, err)
+	}
+}
+
+func TestAttributes_Location(t *testing.T) {
+	a := provider.Attributes{provider.AttrCity: "Sydney", provider.AttrCountryCode: "AU"}
+	if got := a.Location(); got.City != "Sydney" || got.ISOCode != "AU" || got.String() != "Sydney" {
+		t.Errorf("Location() = %+v, want Sydney, AU", got)
+	}
+	var none provider.Attributes
+	if got := none.Location().String(); got != "" {
+		t.Errorf("Location() = %q, want empty", got)
+	}
+}
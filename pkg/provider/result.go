@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+)
+
+// Result is the reply of a single provider request.
+type Result struct {
+	Provider   string            // Provider is the name of the provider.
+	Family     Family            // Family is the requested address family.
+	IP         string            // IP is the returned address, it is empty on an error.
+	Latency    time.Duration     // Latency is the time taken for the provider to reply.
+	Location   geolite2.Location // Location is the geographic location of the IP.
+	Attributes Attributes        // Attributes are the details of the IP reported by the provider, if any.
+	Err        error             // Err is the provider request error.
+}
+
+// OK reports whether the result contains an IP address without an error.
+func (r Result) OK() bool {
+	return r.Err == nil && r.IP != ""
+}
+
+// String returns the IP address with its city and country location.
+func (r Result) String() string {
+	if l := r.Location.String(); l != "" {
+		return r.IP + ", " + l
+	}
+	return r.IP
+}
@@ -0,0 +1,67 @@
+package resolver
+
+import (
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+// Consensus is the agreement of the provider results on a single IP address.
+type Consensus struct {
+	IP      string            // IP is the address returned by the most providers.
+	Agree   []provider.Result // Agree are the results that returned the IP address.
+	Dissent []provider.Result // Dissent are the results that returned a different address.
+	Failed  []provider.Result // Failed are the results that returned an error or no address.
+}
+
+// Vote groups the results by their returned address and
+// returns the consensus of the address with the most replies.
+// Ties are given to the address that was returned first.
+// The results should be of a single address family.
+func Vote(results []provider.Result) Consensus {
+	order, groups := []string{}, map[string][]provider.Result{}
+	c := Consensus{}
+	for _, r := range results {
+		if !r.OK() {
+			c.Failed = append(c.Failed, r)
+			continue
+		}
+		if _, ok := groups[r.IP]; !ok {
+			order = append(order, r.IP)
+		}
+		groups[r.IP] = append(groups[r.IP], r)
+	}
+	for _, ip := range order {
+		if len(groups[ip]) > len(c.Agree) {
+			c.IP, c.Agree = ip, groups[ip]
+		}
+	}
+	for _, ip := range order {
+		if ip != c.IP {
+			c.Dissent = append(c.Dissent, groups[ip]...)
+		}
+	}
+	return c
+}
+
+// Total returns the number of providers that were requested,
+// including the providers that failed to return an address.
+func (c Consensus) Total() int {
+	return len(c.Agree) + len(c.Dissent) + len(c.Failed)
+}
+
+// Quorum reports whether a majority of the Total providers agree on the IP address.
+// The failed providers are counted, so a single reply with
+// every other provider failing is not a quorum.
+func (c Consensus) Quorum() bool {
+	return len(c.Agree) > c.Total()/2
+}
+
+// Filter returns the results of the address family.
+func Filter(results []provider.Result, f provider.Family) []provider.Result {
+	x := []provider.Result{}
+	for _, r := range results {
+		if r.Family&f != 0 {
+			x = append(x, r)
+		}
+	}
+	return x
+}
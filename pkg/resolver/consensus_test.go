@@ -0,0 +1,85 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+)
+
+func TestVote(t *testing.T) {
+	const a, b = "192.0.2.1", "192.0.2.2"
+	res := func(name, ip string) provider.Result {
+		r := provider.Result{Provider: name, IP: ip}
+		if ip == "" {
+			r.Err = errFake
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		results []provider.Result
+		want    string
+		agree   int
+		total   int
+		quorum  bool
+	}{
+		{"none", nil, "", 0, 0, false},
+		{"failed", []provider.Result{res("w", "")}, "", 0, 1, false},
+		{"unanimous", []provider.Result{res("w", a), res("x", a), res("y", a), res("z", a)}, a, 4, 4, true},
+		{"majority", []provider.Result{res("w", b), res("x", a), res("y", a), res("z", a)}, a, 3, 4, true},
+		{"majority failed", []provider.Result{res("w", a), res("x", a), res("y", a), res("z", "")}, a, 3, 4, true},
+		{"half failed", []provider.Result{res("w", b), res("x", a), res("y", a), res("z", "")}, a, 2, 4, false},
+		{"lone reply", []provider.Result{res("w", a), res("x", ""), res("y", ""), res("z", "")}, a, 1, 4, false},
+		{"agree and failed", []provider.Result{
+			res("s", a), res("t", ""), res("u", a), res("v", ""),
+			res("w", ""), res("x", ""), res("y", ""), res("z", ""),
+		}, a, 2, 8, false},
+		{"tie", []provider.Result{res("w", b), res("x", a), res("y", a), res("z", b)}, b, 2, 4, false},
+		{"split", []provider.Result{res("w", b), res("x", a)}, b, 1, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := resolver.Vote(tt.results)
+			if c.IP != tt.want {
+				t.Errorf("Vote() IP = %v, want %v", c.IP, tt.want)
+			}
+			if len(c.Agree) != tt.agree {
+				t.Errorf("Vote() agree = %d, want %d", len(c.Agree), tt.agree)
+			}
+			if c.Total() != tt.total {
+				t.Errorf("Total() = %d, want %d", c.Total(), tt.total)
+			}
+			if c.Quorum() != tt.quorum {
+				t.Errorf("Quorum() = %v, want %v", c.Quorum(), tt.quorum)
+			}
+			if got := len(c.Agree) + len(c.Dissent) + len(c.Failed); got != len(tt.results) {
+				t.Errorf("Vote() = %d results, want %d", got, len(tt.results))
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	results := []provider.Result{
+		{Provider: "a", Family: provider.IPv4},
+		{Provider: "a", Family: provider.IPv6},
+		{Provider: "b", Family: provider.IPv4},
+	}
+	tests := []struct {
+		name string
+		f    provider.Family
+		want int
+	}{
+		{"ipv4", provider.IPv4, 2},
+		{"ipv6", provider.IPv6, 1},
+		{"both", provider.Both, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(resolver.Filter(results, tt.f)); got != tt.want {
+				t.Errorf("Filter() = %d results, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package resolver requests your Internet-facing IPv4 and IPv6 addresses,
+// concurrently sourced from the online APIs of a provider registry.
+// © Ben Garrett https://github.com/bengarrett/myip
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/provider"
+)
+
+var ErrNoIP = errors.New("no provider returned an ip address")
+
+// Resolver requests the address families from every provider in a registry.
+type Resolver struct {
+	Family   provider.Family    // Family is the address family, or families, to request.
+	Timeout  time.Duration      // Timeout is the duration given to each request.
+	Registry *provider.Registry // Registry of providers, a nil value uses provider.Default.
+}
+
+// job is a single provider request of an address family.
+type job struct {
+	p provider.Provider
+	f provider.Family
+}
+
+// New returns a resolver of the address family using the default registry.
+func New(f provider.Family, timeout time.Duration) Resolver {
+	return Resolver{Family: f, Timeout: timeout}
+}
+
+func (r Resolver) registry() *provider.Registry {
+	if r.Registry == nil {
+		return provider.Default
+	}
+	return r.Registry
+}
+
+// jobs returns every provider and family pair to request.
+// Providers without an endpoint for a family are skipped.
+func (r Resolver) jobs() []job {
+	jobs := []job{}
+	for _, f := range r.Family.Families() {
+		for _, p := range r.registry().Providers() {
+			if p.Endpoint(f) == "" {
+				continue
+			}
+			jobs = append(jobs, job{p: p, f: f})
+		}
+	}
+	return jobs
+}
+
+// Len returns the number of requests made by Stream and Results.
+func (r Resolver) Len() int {
+	return len(r.jobs())
+}
+
+// Stream queries every provider for the address family and
+// sends each result to the returned channel as the replies come in.
+// Every request is given its own timeout and the channel is closed
+// once all the providers have replied.
+func (r Resolver) Stream(ctx context.Context) <-chan provider.Result {
+	jobs := r.jobs()
+	c := make(chan provider.Result, len(jobs))
+	done := make(chan struct{})
+	for _, j := range jobs {
+		go func(j job) {
+			ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+			c <- request(ctx, cancel, j)
+			done <- struct{}{}
+		}(j)
+	}
+	go func() {
+		for range jobs {
+			<-done
+		}
+		close(c)
+	}()
+	return c
+}
+
+// Results queries every provider for the address family and
+// returns all the results in the order the replies came in.
+func (r Resolver) Results(ctx context.Context) []provider.Result {
+	results := []provider.Result{}
+	for x := range r.Stream(ctx) {
+		results = append(results, x)
+	}
+	return results
+}
+
+// First queries every provider for the address family and
+// returns the result of the quickest successful reply.
+// All other requests are then aborted.
+func (r Resolver) First(ctx context.Context) (provider.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var err error
+	for x := range r.Stream(ctx) {
+		if x.OK() {
+			return x, nil
+		}
+		if x.Err != nil {
+			err = x.Err
+		}
+	}
+	if err != nil {
+		return provider.Result{}, fmt.Errorf("%w: %w", ErrNoIP, err)
+	}
+	return provider.Result{}, ErrNoIP
+}
+
+func request(ctx context.Context, cancel context.CancelFunc, j job) provider.Result {
+	r := provider.Result{Provider: j.p.Name(), Family: j.f}
+	start := time.Now()
+	if d, ok := j.p.(provider.Describer); ok {
+		r.IP, r.Attributes, r.Err = d.Describe(ctx, cancel, j.f)
+	} else {
+		r.IP, r.Err = j.p.Request(ctx, cancel, j.f)
+	}
+	r.Latency = time.Since(start)
+	if r.Err != nil {
+		r.IP, r.Attributes = "", nil
+		return r
+	}
+	if r.IP != "" {
+		r.Location, _ = geolite2.Lookup(r.IP)
+	}
+	if r.Location == (geolite2.Location{}) {
+		// fallback to the location reported by the provider
+		r.Location = r.Attributes.Location()
+	}
+	return r
+}
@@ -0,0 +1,142 @@
+package resolver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+)
+
+const timeout = 5 * time.Second
+
+var errFake = errors.New("fake error")
+
+// reply returns a provider request that replies with the ip address and error.
+func reply(ip string, err error) provider.Request {
+	return func(_ context.Context, cancel context.CancelFunc) (string, error) {
+		defer cancel()
+		return ip, err
+	}
+}
+
+func registry() *provider.Registry {
+	return provider.NewRegistry(
+		provider.Service{ID: "a", Linkv4: "a4", Linkv6: "a6", IPv4: reply("0.0.0.0", nil), IPv6: reply("::", nil)},
+		provider.Service{ID: "b", Linkv4: "b4", IPv4: reply("", errFake)},
+		provider.Service{ID: "c", Linkv6: "c6", IPv6: reply("::1", nil)},
+	)
+}
+
+func TestResolver_Len(t *testing.T) {
+	tests := []struct {
+		name string
+		f    provider.Family
+		want int
+	}{
+		{"ipv4", provider.IPv4, 2},
+		{"ipv6", provider.IPv6, 2},
+		{"both", provider.Both, 4},
+		{"none", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := resolver.Resolver{Family: tt.f, Timeout: timeout, Registry: registry()}
+			if got := r.Len(); got != tt.want {
+				t.Errorf("Len() = %d, want %d", got, tt.want)
+			}
+			if got := len(r.Results(context.Background())); got != tt.want {
+				t.Errorf("Results() = %d results, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Results(t *testing.T) {
+	r := resolver.Resolver{Family: provider.Both, Timeout: timeout, Registry: registry()}
+	for _, x := range r.Results(context.Background()) {
+		switch {
+		case x.Provider == "b":
+			if !errors.Is(x.Err, errFake) || x.IP != "" {
+				t.Errorf("Results() b = %q, %v, want %v", x.IP, x.Err, errFake)
+			}
+		case x.Family == provider.IPv4:
+			if x.IP != "0.0.0.0" {
+				t.Errorf("Results() %s %s = %q, want 0.0.0.0", x.Provider, x.Family, x.IP)
+			}
+		case x.Family == provider.IPv6:
+			if !x.OK() {
+				t.Errorf("Results() %s %s = %q, %v, want ok", x.Provider, x.Family, x.IP, x.Err)
+			}
+		}
+	}
+}
+
+func TestResolver_First(t *testing.T) {
+	r := resolver.Resolver{Family: provider.IPv4, Timeout: timeout, Registry: registry()}
+	x, err := r.First(context.Background())
+	if err != nil || x.Provider != "a" {
+		t.Errorf("First() = %v, %v, want provider a", x.Provider, err)
+	}
+	r.Registry = provider.NewRegistry(provider.Service{ID: "b", Linkv4: "b4", IPv4: reply("", errFake)})
+	if _, err := r.First(context.Background()); !errors.Is(err, resolver.ErrNoIP) || !errors.Is(err, errFake) {
+		t.Errorf("First() error = %v, want %v", err, resolver.ErrNoIP)
+	}
+}
+
+func TestResolver_Attributes(t *testing.T) {
+	reg := provider.NewRegistry(
+		myiptest.Provider(t, myiptest.IPinfo, myiptest.OK),
+		myiptest.Provider(t, myiptest.CFTrace, myiptest.OK),
+		myiptest.Provider(t, myiptest.Ipify, myiptest.OK),
+		myiptest.Provider(t, myiptest.MyIPcom, myiptest.Status),
+	)
+	r := resolver.Resolver{Family: provider.IPv4, Timeout: timeout, Registry: reg}
+	for _, x := range r.Results(context.Background()) {
+		switch x.Provider {
+		case "ipinfo":
+			if x.Attributes[provider.AttrASN] != "AS64496" || x.Attributes[provider.AttrOrg] != "Example Networks" {
+				t.Errorf("Results() ipinfo attributes = %v, want the asn and org", x.Attributes)
+			}
+			if x.Location.City != "Sydney" || x.Location.ISOCode != "AU" {
+				t.Errorf("Results() ipinfo location = %+v, want the reported location", x.Location)
+			}
+		case "cftrace":
+			if x.Attributes[provider.AttrColo] != "SYD" || x.Attributes[provider.AttrWARP] != "on" {
+				t.Errorf("Results() cftrace attributes = %v, want the colo and warp", x.Attributes)
+			}
+		case "ipify", "myipcom":
+			if x.Attributes != nil {
+				t.Errorf("Results() %s attributes = %v, want nil", x.Provider, x.Attributes)
+			}
+		}
+	}
+}
+
+func TestResolver_Servers(t *testing.T) {
+	r := resolver.Resolver{Family: provider.Both, Timeout: timeout, Registry: myiptest.Registry(t, myiptest.OK)}
+	const want = 7 // myip.com does not reply with an IPv6 address
+	ok := 0
+	for _, x := range r.Results(context.Background()) {
+		if !x.OK() {
+			continue
+		}
+		ok++
+		if x.Family == provider.IPv4 && x.IP != myiptest.IPv4 {
+			t.Errorf("Results() %s = %v, want %v", x.Provider, x.IP, myiptest.IPv4)
+		}
+		if x.Family == provider.IPv6 && x.IP != myiptest.IPv6 {
+			t.Errorf("Results() %s = %v, want %v", x.Provider, x.IP, myiptest.IPv6)
+		}
+	}
+	if ok != want {
+		t.Errorf("Results() = %d valid results, want %d", ok, want)
+	}
+	r.Registry = myiptest.Registry(t, myiptest.Status)
+	if _, err := r.First(context.Background()); !errors.Is(err, resolver.ErrNoIP) {
+		t.Errorf("First() error = %v, want %v", err, resolver.ErrNoIP)
+	}
+}
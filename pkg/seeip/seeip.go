@@ -12,6 +12,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+
+	"github.com/bengarrett/myip/pkg/fault"
 )
 
 // https://seeip.org
@@ -22,6 +24,7 @@ import (
 var (
 	ErrNoIP    = errors.New("ip address is empty")
 	ErrInvalid = errors.New("ip address is invalid")
+	// Deprecated: ErrRequest is unused, failed requests return a *fault.Error.
 	ErrRequest = errors.New("myip.com error")
 	ErrStatus  = errors.New("unusual seeip.org server response")
 )
@@ -32,48 +35,84 @@ const (
 	Linkv6 = "https://ip6.seeip.org"
 )
 
+// Client requests the seeip API using a HTTP client and endpoint URLs.
+// The zero value uses http.DefaultClient with the Linkv4 and Linkv6 URLs.
+type Client struct {
+	HTTP   *http.Client // HTTP client for the requests, a nil value uses http.DefaultClient.
+	Linkv4 string       // Linkv4 is the IPv4 endpoint URL, an empty value uses Linkv4.
+	Linkv6 string       // Linkv6 is the IPv6 endpoint URL, an empty value uses Linkv6.
+}
+
+func (c Client) client() *http.Client {
+	if c.HTTP == nil {
+		return http.DefaultClient
+	}
+	return c.HTTP
+}
+
+// URLs returns the IPv4 and IPv6 endpoint URLs used by the client.
+func (c Client) URLs() (string, string) {
+	v4, v6 := c.Linkv4, c.Linkv6
+	if v4 == "" {
+		v4 = Linkv4
+	}
+	if v6 == "" {
+		v6 = Linkv6
+	}
+	return v4, v6
+}
+
 // IPv4 returns the clients online IP address.
 func IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	return Request(ctx, cancel, Linkv4)
+	return Client{}.IPv4(ctx, cancel)
 }
 
 // IPv6 returns the clients online IP address. Using this on a network
 // that does not support IPv6 will result in an error.
 func IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
-	return Request(ctx, cancel, Linkv6)
+	return Client{}.IPv6(ctx, cancel)
 }
 
 // Request the seeip API and return a valid IPv4 or IPv6 address.
 func Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
-	b, err := RequestB(ctx, cancel, url)
-	if b == nil && err == nil && errors.Is(ctx.Err(), context.Canceled) {
-		return "", nil
-	}
+	return Client{}.Request(ctx, cancel, url)
+}
+
+// RequestB requests the seeip API and return the response body.
+func RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byte, error) {
+	return Client{}.RequestB(ctx, cancel, url)
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	v4, _ := c.URLs()
+	return c.Request(ctx, cancel, v4)
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	_, v6 := c.URLs()
+	return c.Request(ctx, cancel, v6)
+}
+
+// Request the seeip API and return a valid IPv4 or IPv6 address.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, url string) (string, error) {
+	b, err := c.RequestB(ctx, cancel, url)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			fmt.Printf("\n%s: timeout", domain)
-			return "", nil
-		}
-		if err == nil && errors.Is(ctx.Err(), context.Canceled) {
-			return "", nil
-		}
-		if errors.Is(errors.Unwrap(err), context.Canceled) {
-			return "", nil
-		}
-		e := fmt.Errorf("%w: %w", ErrRequest, err)
-		return "", e
+		return "", fault.New(domain, err)
 	}
 
 	ip := string(b)
 	if err := Valid(ip); err != nil {
-		return ip, err
+		return ip, fault.Wrap(domain, fault.Validation, err)
 	}
 
 	return ip, nil
 }
 
 // RequestB requests the seeip API and return the response body.
-func RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byte, error) {
+func (c Client) RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byte, error) {
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -81,19 +120,19 @@ func RequestB(ctx context.Context, cancel context.CancelFunc, url string) ([]byt
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus)
+		return nil, fault.Wrap(domain, fault.Status, fmt.Errorf("%s, %w", strings.ToLower(resp.Status), ErrStatus))
 	}
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, fault.Wrap(domain, fault.Parse, err)
 	}
 
 	return b, nil
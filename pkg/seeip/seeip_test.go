@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/myiptest"
 	"github.com/bengarrett/myip/pkg/seeip"
 )
 
@@ -72,8 +74,8 @@ func ExampleIPv6() {
 
 func TestTimeout(t *testing.T) {
 	ctx, timeout := context.WithTimeout(context.Background(), 0*time.Second)
-	if _, err := seeip.IPv4(ctx, timeout); !errors.Is(err, nil) {
-		t.Errorf("IPv4() = %v, want %v", err, nil)
+	if _, err := seeip.IPv4(ctx, timeout); !fault.Is(err, fault.Timeout) {
+		t.Errorf("IPv4() = %v, want %v", err, fault.Timeout)
 	}
 }
 
@@ -81,11 +83,11 @@ func TestCancel(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 	s, err := seeip.IPv4(ctx, cancel)
-	if s != "" || err != nil {
-		t.Errorf("IPv4() s = %v, error = %v, want an empty string with no errors", s, err)
+	if s != "" || !fault.Is(err, fault.Canceled) {
+		t.Errorf("IPv4() s = %v, error = %v, want an empty string with a %v error", s, err, fault.Canceled)
 	}
-	if want := context.Canceled; !errors.Is(ctx.Err(), want) {
-		t.Errorf("IPv4() context.error = %v, want %v", err, want)
+	if want := context.Canceled; !errors.Is(err, want) {
+		t.Errorf("IPv4() error = %v, want %v", err, want)
 	}
 }
 
@@ -120,3 +122,66 @@ func TestValid(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestB(t *testing.T) {
+	ok := myiptest.NewServer(myiptest.SeeIP, myiptest.OK)
+	defer ok.Close()
+	status := myiptest.NewServer(myiptest.SeeIP, myiptest.Status)
+	defer status.Close()
+	tests := []struct {
+		name    string
+		domain  string
+		want    string
+		wantErr error
+	}{
+		{"503", status.Linkv4, "", seeip.ErrStatus},
+		{"ipv4", ok.Linkv4, myiptest.IPv4, nil},
+		{"ipv6", ok.Linkv6, myiptest.IPv6, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, timeout := context.WithTimeout(context.Background(), 5*time.Second)
+			b, err := seeip.RequestB(ctx, timeout, tt.domain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RequestB() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := string(b); got != tt.want {
+				t.Errorf("RequestB() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		ipv6     bool
+		want     string
+		wantErr  error
+	}{
+		{"ipv4", myiptest.OK, false, myiptest.IPv4, nil},
+		{"ipv6", myiptest.OK, true, myiptest.IPv6, nil},
+		{"malformed", myiptest.Malformed, false, "", seeip.ErrInvalid},
+		{"status", myiptest.Status, true, "", seeip.ErrStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewServer(myiptest.SeeIP, tt.behavior)
+			defer srv.Close()
+			c := seeip.Client{HTTP: srv.Client(), Linkv4: srv.Linkv4, Linkv6: srv.Linkv6}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			get := c.IPv4
+			if tt.ipv6 {
+				get = c.IPv6
+			}
+			s, err := get(ctx, cancel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && s != tt.want {
+				t.Errorf("Client() = %v, want %v", s, tt.want)
+			}
+		})
+	}
+}
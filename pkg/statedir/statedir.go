@@ -0,0 +1,45 @@
+// Package statedir locates the directory of the files that myip
+// keeps between runs, such as the last known addresses and the history.
+// © Ben Garrett https://github.com/bengarrett/myip
+package statedir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+var ErrHome = errors.New("could not determine the home directory")
+
+// Dir returns the directory used for the myip state files.
+// It uses the XDG_STATE_HOME environment variable when set, otherwise
+// $HOME/.local/state on Unix systems or %LocalAppData% on Windows.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "myip"), nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "myip"), nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrHome, err)
+	}
+	if home == "" {
+		return "", ErrHome
+	}
+	return filepath.Join(home, ".local", "state", "myip"), nil
+}
+
+// File returns the path of the named file in the state directory.
+func File(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
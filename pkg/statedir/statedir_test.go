@@ -0,0 +1,36 @@
+package statedir_test
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/statedir"
+)
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	if dir, err := statedir.Dir(); err != nil || dir != filepath.Join("/tmp/state", "myip") {
+		t.Errorf("Dir() = %v, %v, want %v", dir, err, "/tmp/state/myip")
+	}
+	if name, err := statedir.File("last.json"); err != nil || name != filepath.Join("/tmp/state", "myip", "last.json") {
+		t.Errorf("File() = %v, %v, want %v", name, err, "/tmp/state/myip/last.json")
+	}
+}
+
+func TestDir_noHome(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("the home directory is not read from HOME")
+	}
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "")
+	_, err := statedir.Dir()
+	if !errors.Is(err, statedir.ErrHome) {
+		t.Fatalf("Dir() error = %v, want %v", err, statedir.ErrHome)
+	}
+	if strings.Contains(err.Error(), "%!") {
+		t.Errorf("Dir() error = %q, contains a formatting verb", err)
+	}
+}
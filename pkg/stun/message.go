@@ -0,0 +1,225 @@
+package stun
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+var (
+	ErrMessage   = errors.New("stun message is malformed")
+	ErrAttribute = errors.New("stun attribute is missing")
+	ErrFamily    = errors.New("stun address family is unknown")
+)
+
+// MagicCookie is the fixed value that identifies a RFC 5389 STUN message.
+const MagicCookie uint32 = 0x2112a442
+
+// Message types.
+const (
+	BindingRequest uint16 = 0x0001 // BindingRequest is a Binding request.
+	BindingSuccess uint16 = 0x0101 // BindingSuccess is a Binding success response.
+	BindingError   uint16 = 0x0111 // BindingError is a Binding error response.
+)
+
+// Attribute types.
+const (
+	AttrMappedAddress    uint16 = 0x0001 // AttrMappedAddress is the reflexive address of the client.
+	AttrChangeRequest    uint16 = 0x0003 // AttrChangeRequest asks the server to reply from its other address or port, RFC 5780.
+	AttrErrorCode        uint16 = 0x0009 // AttrErrorCode is the error code and reason of an error response.
+	AttrXORMappedAddress uint16 = 0x0020 // AttrXORMappedAddress is the obfuscated reflexive address of the client.
+	AttrSoftware         uint16 = 0x8022 // AttrSoftware is a description of the agent software.
+	AttrResponseOrigin   uint16 = 0x802b // AttrResponseOrigin is the address the response was sent from, RFC 5780.
+	AttrOtherAddress     uint16 = 0x802c // AttrOtherAddress is the alternate address of the server, RFC 5780.
+)
+
+// CHANGE-REQUEST flags.
+const (
+	ChangeIP   uint32 = 0x04 // ChangeIP asks the server to reply from its other IP address.
+	ChangePort uint32 = 0x02 // ChangePort asks the server to reply from its other port.
+)
+
+const (
+	headerLen = 20
+	familyV4  = 0x01
+	familyV6  = 0x02
+)
+
+// Attribute is a type-length-value attribute of a message.
+type Attribute struct {
+	Type  uint16
+	Value []byte
+}
+
+// Message is a STUN message.
+type Message struct {
+	Type       uint16
+	ID         [12]byte // ID is the transaction ID.
+	Attributes []Attribute
+}
+
+// NewRequest returns a Binding request with a random transaction ID.
+// It returns an error when the random number generator fails.
+func NewRequest(attrs ...Attribute) (Message, error) {
+	m := Message{Type: BindingRequest, Attributes: attrs}
+	if _, err := rand.Read(m.ID[:]); err != nil {
+		return Message{}, fmt.Errorf("transaction id: %w", err)
+	}
+	return m, nil
+}
+
+// Pack returns the message in the STUN wire format.
+func (m Message) Pack() []byte {
+	b := make([]byte, headerLen, 128)
+	for _, a := range m.Attributes {
+		b = binary.BigEndian.AppendUint16(b, a.Type)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.Value)))
+		b = append(b, a.Value...)
+		// attributes are padded to a multiple of 4 bytes
+		for len(b)%4 != 0 {
+			b = append(b, 0)
+		}
+	}
+	binary.BigEndian.PutUint16(b[0:], m.Type)
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)-headerLen))
+	binary.BigEndian.PutUint32(b[4:], MagicCookie)
+	copy(b[8:headerLen], m.ID[:])
+	return b
+}
+
+// Unpack parses a message in the STUN wire format.
+func Unpack(b []byte) (Message, error) {
+	if len(b) < headerLen {
+		return Message{}, fmt.Errorf("%w: short header", ErrMessage)
+	}
+	// the two most significant bits of every stun message are zero
+	if b[0]&0xc0 != 0 || binary.BigEndian.Uint32(b[4:]) != MagicCookie {
+		return Message{}, fmt.Errorf("%w: not a stun message", ErrMessage)
+	}
+	size := int(binary.BigEndian.Uint16(b[2:]))
+	if size%4 != 0 || len(b) < headerLen+size {
+		return Message{}, fmt.Errorf("%w: bad length", ErrMessage)
+	}
+	m := Message{Type: binary.BigEndian.Uint16(b[0:])}
+	copy(m.ID[:], b[8:headerLen])
+	b = b[headerLen : headerLen+size]
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return Message{}, fmt.Errorf("%w: short attribute", ErrMessage)
+		}
+		typ, n := binary.BigEndian.Uint16(b[0:]), int(binary.BigEndian.Uint16(b[2:]))
+		padded := (n + 3) &^ 3
+		if len(b) < 4+padded {
+			return Message{}, fmt.Errorf("%w: short attribute value", ErrMessage)
+		}
+		m.Attributes = append(m.Attributes, Attribute{Type: typ, Value: b[4 : 4+n]})
+		b = b[4+padded:]
+	}
+	return m, nil
+}
+
+// Get returns the value of the first attribute of the type.
+func (m Message) Get(typ uint16) ([]byte, bool) {
+	for _, a := range m.Attributes {
+		if a.Type == typ {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Mapped returns the reflexive transport address of the client, using the
+// XOR-MAPPED-ADDRESS attribute or the MAPPED-ADDRESS of older servers.
+func (m Message) Mapped() (netip.AddrPort, error) {
+	if v, ok := m.Get(AttrXORMappedAddress); ok {
+		return Address(xor(v, m.ID))
+	}
+	if v, ok := m.Get(AttrMappedAddress); ok {
+		return Address(v)
+	}
+	return netip.AddrPort{}, fmt.Errorf("%w: xor-mapped-address", ErrAttribute)
+}
+
+// Other returns the alternate transport address of the server from the OTHER-ADDRESS attribute.
+func (m Message) Other() (netip.AddrPort, error) {
+	if v, ok := m.Get(AttrOtherAddress); ok {
+		return Address(v)
+	}
+	return netip.AddrPort{}, fmt.Errorf("%w: other-address", ErrAttribute)
+}
+
+// Error returns the error code and reason of an error response.
+func (m Message) Error() (int, string) {
+	v, ok := m.Get(AttrErrorCode)
+	if !ok || len(v) < 4 {
+		return 0, ""
+	}
+	return int(v[2]&0x07)*100 + int(v[3]), strings.TrimSpace(string(v[4:]))
+}
+
+// xor returns a copy of the address attribute value with the port and address
+// obfuscated, or revealed, using the magic cookie and the transaction ID.
+func xor(v []byte, id [12]byte) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key, MagicCookie)
+	copy(key[4:], id[:])
+	x := make([]byte, len(v))
+	copy(x, v)
+	if len(x) >= 4 {
+		x[2] ^= key[0]
+		x[3] ^= key[1]
+	}
+	for i := 4; i < len(x) && i-4 < len(key); i++ {
+		x[i] ^= key[i-4]
+	}
+	return x
+}
+
+// Address returns the transport address of a MAPPED-ADDRESS formatted attribute value.
+func Address(v []byte) (netip.AddrPort, error) {
+	if len(v) < 4 {
+		return netip.AddrPort{}, fmt.Errorf("%w: short address", ErrMessage)
+	}
+	port := binary.BigEndian.Uint16(v[2:])
+	switch {
+	case v[1] == familyV4 && len(v) == 8:
+		return netip.AddrPortFrom(netip.AddrFrom4([4]byte(v[4:8])), port), nil
+	case v[1] == familyV6 && len(v) == 20:
+		return netip.AddrPortFrom(netip.AddrFrom16([16]byte(v[4:20])), port), nil
+	case v[1] != familyV4 && v[1] != familyV6:
+		return netip.AddrPort{}, fmt.Errorf("%w: %d", ErrFamily, v[1])
+	}
+	return netip.AddrPort{}, fmt.Errorf("%w: bad address length", ErrMessage)
+}
+
+// AddressAttr returns an attribute of the type containing the transport address,
+// which is obfuscated using the transaction ID for a XOR-MAPPED-ADDRESS.
+func AddressAttr(typ uint16, ap netip.AddrPort, id [12]byte) Attribute {
+	ip := ap.Addr().Unmap()
+	v := []byte{0, familyV4, 0, 0}
+	if ip.Is6() {
+		v[1] = familyV6
+	}
+	binary.BigEndian.PutUint16(v[2:], ap.Port())
+	v = append(v, ip.AsSlice()...)
+	if typ == AttrXORMappedAddress {
+		v = xor(v, id)
+	}
+	return Attribute{Type: typ, Value: v}
+}
+
+// ChangeRequest returns a CHANGE-REQUEST attribute containing the ChangeIP and ChangePort flags.
+func ChangeRequest(flags uint32) Attribute {
+	return Attribute{Type: AttrChangeRequest, Value: binary.BigEndian.AppendUint32(nil, flags)}
+}
+
+// Flags returns the flags of a CHANGE-REQUEST attribute, or zero when there is none.
+func (m Message) Flags() uint32 {
+	if v, ok := m.Get(AttrChangeRequest); ok && len(v) == 4 {
+		return binary.BigEndian.Uint32(v)
+	}
+	return 0
+}
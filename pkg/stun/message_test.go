@@ -0,0 +1,106 @@
+package stun_test
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/bengarrett/myip/pkg/stun"
+)
+
+// id is the transaction ID of the RFC 5769 test vectors.
+var id = [12]byte{0xb7, 0xe7, 0xa7, 0x01, 0xbc, 0x34, 0xd6, 0x86, 0xfa, 0x87, 0xdf, 0xae} //nolint: gochecknoglobals
+
+func TestMessage_Mapped(t *testing.T) {
+	tests := []struct {
+		name string
+		attr stun.Attribute
+		want string
+		err  error
+	}{
+		// the rfc 5769 sample ipv4 response
+		{"xor ipv4", stun.Attribute{Type: stun.AttrXORMappedAddress,
+			Value: []byte{0x00, 0x01, 0xa1, 0x47, 0xe1, 0x12, 0xa6, 0x43}}, "192.0.2.1:32853", nil},
+		// the rfc 5769 sample ipv6 response
+		{"xor ipv6", stun.Attribute{Type: stun.AttrXORMappedAddress, Value: []byte{
+			0x00, 0x02, 0xa1, 0x47, 0x01, 0x13, 0xa9, 0xfa, 0xa5, 0xd3, 0xf1, 0x79,
+			0xbc, 0x25, 0xf4, 0xb5, 0xbe, 0xd2, 0xb9, 0xd9,
+		}}, "[2001:db8:1234:5678:11:2233:4455:6677]:32853", nil},
+		{"mapped", stun.Attribute{Type: stun.AttrMappedAddress,
+			Value: []byte{0x00, 0x01, 0x80, 0x55, 192, 0, 2, 1}}, "192.0.2.1:32853", nil},
+		{"family", stun.Attribute{Type: stun.AttrMappedAddress,
+			Value: []byte{0x00, 0x03, 0x80, 0x55, 192, 0, 2, 1}}, "", stun.ErrFamily},
+		{"short", stun.Attribute{Type: stun.AttrMappedAddress,
+			Value: []byte{0x00, 0x01, 0x80, 0x55, 192}}, "", stun.ErrMessage},
+		{"missing", stun.Attribute{Type: stun.AttrSoftware, Value: []byte("test")}, "", stun.ErrAttribute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := stun.Message{Type: stun.BindingSuccess, ID: id, Attributes: []stun.Attribute{tt.attr}}
+			got, err := m.Mapped()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Mapped() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("Mapped() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressAttr(t *testing.T) {
+	for _, s := range []string{"192.0.2.1:32853", "[2001:db8::1]:3478"} {
+		ap := netip.MustParseAddrPort(s)
+		m := stun.Message{ID: id, Attributes: []stun.Attribute{stun.AddressAttr(stun.AttrXORMappedAddress, ap, id)}}
+		if got, err := m.Mapped(); err != nil || got != ap {
+			t.Errorf("AddressAttr() Mapped() = %v, %v, want %v", got, err, ap)
+		}
+		m.Attributes = []stun.Attribute{stun.AddressAttr(stun.AttrOtherAddress, ap, id)}
+		if got, err := m.Other(); err != nil || got != ap {
+			t.Errorf("AddressAttr() Other() = %v, %v, want %v", got, err, ap)
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	req, err := stun.NewRequest(stun.ChangeRequest(stun.ChangeIP|stun.ChangePort),
+		stun.Attribute{Type: stun.AttrSoftware, Value: []byte("myip")})
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	b := req.Pack()
+	if len(b) != 20+8+8 || !bytes.Equal(b[4:8], []byte{0x21, 0x12, 0xa4, 0x42}) {
+		t.Errorf("Pack() = %x, want a padded 36 byte message", b)
+	}
+	m, err := stun.Unpack(b)
+	if err != nil || m.ID != req.ID || m.Flags() != stun.ChangeIP|stun.ChangePort {
+		t.Errorf("Unpack() = %+v, %v, want the request", m, err)
+	}
+	if v, ok := m.Get(stun.AttrSoftware); !ok || string(v) != "myip" {
+		t.Errorf("Get() = %q, want the unpadded software value", v)
+	}
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"short", b[:12]},
+		{"cookie", append(append([]byte{}, b[:4]...), make([]byte, 32)...)},
+		{"length", b[:30]},
+		{"http", []byte("HTTP/1.1 400 Bad Request\r\n\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := stun.Unpack(tt.b); !errors.Is(err, stun.ErrMessage) {
+				t.Errorf("Unpack() error = %v, want %v", err, stun.ErrMessage)
+			}
+		})
+	}
+}
+
+func TestMessage_Error(t *testing.T) {
+	m := stun.Message{Attributes: []stun.Attribute{{Type: stun.AttrErrorCode, Value: []byte("\x00\x00\x04\x14Unknown Attribute")}}}
+	if code, reason := m.Error(); code != 420 || reason != "Unknown Attribute" {
+		t.Errorf("Error() = %d %q, want 420 Unknown Attribute", code, reason)
+	}
+}
@@ -0,0 +1,249 @@
+package stun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+var (
+	ErrRFC5780 = errors.New("stun server does not support rfc 5780 behavior discovery")
+	ErrChange  = errors.New("stun server ignored the change-request")
+)
+
+// Stunprotocol is a public STUN server that supports the RFC 5780 behavior discovery.
+const Stunprotocol = "stun:stun.stunprotocol.org:3478"
+
+// Behavior is the mapping or filtering behavior of a NAT, as described in RFC 4787.
+type Behavior uint8
+
+const (
+	Unknown              Behavior = iota // Unknown is an untested behavior.
+	EndpointIndependent                  // EndpointIndependent reuses the mapping, or accepts packets, for any remote address.
+	AddressDependent                     // AddressDependent depends on the remote IP address.
+	AddressPortDependent                 // AddressPortDependent depends on the remote IP address and port.
+)
+
+func (b Behavior) String() string {
+	switch b {
+	case Unknown:
+		return "unknown"
+	case EndpointIndependent:
+		return "endpoint-independent"
+	case AddressDependent:
+		return "address-dependent"
+	case AddressPortDependent:
+		return "address-and-port-dependent"
+	}
+	return fmt.Sprintf("behavior(%d)", uint8(b))
+}
+
+// NAT is the result of the RFC 5780 behavior discovery.
+type NAT struct {
+	Local     netip.AddrPort // Local is the transport address of the client.
+	Mapped    netip.AddrPort // Mapped is the reflexive transport address reported by the server.
+	Server    netip.AddrPort // Server is the primary transport address of the server.
+	Other     netip.AddrPort // Other is the alternate transport address of the server.
+	Mapping   Behavior       // Mapping is the mapping behavior of the NAT.
+	Filtering Behavior       // Filtering is the filtering behavior of the NAT.
+}
+
+// Translated reports whether the client is behind a NAT,
+// which is when the reflexive and local addresses differ.
+func (n NAT) Translated() bool {
+	return n.Mapped != n.Local
+}
+
+// Type returns the classic RFC 3489 name of the NAT, which combines the behaviors.
+func (n NAT) Type() string {
+	switch {
+	case !n.Translated() && n.Filtering == EndpointIndependent:
+		return "open internet"
+	case !n.Translated():
+		return "firewall"
+	case n.Mapping == Unknown || n.Filtering == Unknown:
+		return "unknown"
+	case n.Mapping != EndpointIndependent:
+		return "symmetric"
+	case n.Filtering == EndpointIndependent:
+		return "full cone"
+	case n.Filtering == AddressDependent:
+		return "restricted cone"
+	}
+	return "port restricted cone"
+}
+
+// Detect runs the RFC 5780 mapping and filtering behavior tests against the server
+// of the link, which must support the CHANGE-REQUEST and OTHER-ADDRESS attributes.
+// The mapping tests use the same local port, as the mapping of a NAT is per local port.
+func (c Client) Detect(ctx context.Context, link string, ipv6 bool) (NAT, error) {
+	network := "4"
+	if ipv6 {
+		network = "6"
+	}
+	server, err := Parse(link)
+	if err != nil {
+		return NAT{}, fault.Wrap(domain, fault.Request, err)
+	}
+	host, _, _ := net.SplitHostPort(server)
+	raddr, err := resolve(ctx, network, server)
+	if err != nil {
+		return NAT{}, fault.New(host, err)
+	}
+	conn, err := net.ListenUDP("udp"+network, nil)
+	if err != nil {
+		return NAT{}, fault.New(host, err)
+	}
+	defer conn.Close()
+	n := NAT{Server: raddr.AddrPort()}
+	if n.Local, err = local(conn, raddr); err != nil {
+		return NAT{}, fault.New(host, err)
+	}
+	if err := c.mapping(ctx, conn, &n); err != nil {
+		return n, fault.New(host, err)
+	}
+	// the mapping tests open the nat filter to the alternate address,
+	// so the filtering tests need a new local port
+	filter, err := net.ListenUDP("udp"+network, nil)
+	if err != nil {
+		return n, fault.New(host, err)
+	}
+	defer filter.Close()
+	if err := c.filtering(ctx, filter, &n); err != nil {
+		return n, fault.New(host, err)
+	}
+	return n, nil
+}
+
+// mapping runs the mapping behavior tests of RFC 5780 section 4.3.
+func (c Client) mapping(ctx context.Context, conn net.PacketConn, n *NAT) error {
+	// test I, the primary address
+	res, _, err := c.Binding(ctx, conn, net.UDPAddrFromAddrPort(n.Server))
+	if err != nil {
+		return err
+	}
+	if n.Mapped, err = res.Mapped(); err != nil {
+		return err
+	}
+	if n.Other, err = res.Other(); err != nil {
+		return fmt.Errorf("%w: %w", ErrRFC5780, err)
+	}
+	if n.Other.Addr() == n.Server.Addr() || n.Other.Port() == n.Server.Port() {
+		return fmt.Errorf("%w: other-address %s", ErrRFC5780, n.Other)
+	}
+	if !n.Translated() {
+		n.Mapping = EndpointIndependent
+		return nil
+	}
+	// test II, the alternate address and the primary port
+	res, _, err = c.Binding(ctx, conn, net.UDPAddrFromAddrPort(netip.AddrPortFrom(n.Other.Addr(), n.Server.Port())))
+	if err != nil {
+		return err
+	}
+	x2, err := res.Mapped()
+	if err != nil {
+		return err
+	}
+	if x2 == n.Mapped {
+		n.Mapping = EndpointIndependent
+		return nil
+	}
+	// test III, the alternate address and port
+	res, _, err = c.Binding(ctx, conn, net.UDPAddrFromAddrPort(n.Other))
+	if err != nil {
+		return err
+	}
+	x3, err := res.Mapped()
+	if err != nil {
+		return err
+	}
+	n.Mapping = AddressPortDependent
+	if x3 == x2 {
+		n.Mapping = AddressDependent
+	}
+	return nil
+}
+
+// filtering runs the filtering behavior tests of RFC 5780 section 4.4.
+// A filtered test has no response, so each test waits for a shorter duration.
+func (c Client) filtering(ctx context.Context, conn net.PacketConn, n *NAT) error {
+	server := net.UDPAddrFromAddrPort(n.Server)
+	// test II, ask for a response from the alternate address and port
+	from, err := c.changed(ctx, conn, server, ChangeIP|ChangePort)
+	if err != nil {
+		return err
+	}
+	if from.IsValid() {
+		if from.Addr() == n.Server.Addr() {
+			return fmt.Errorf("%w: response from %s", ErrChange, from)
+		}
+		n.Filtering = EndpointIndependent
+		return nil
+	}
+	// test III, ask for a response from the primary address and the alternate port
+	if from, err = c.changed(ctx, conn, server, ChangePort); err != nil {
+		return err
+	}
+	if from.IsValid() {
+		if from.Port() == n.Server.Port() {
+			return fmt.Errorf("%w: response from %s", ErrChange, from)
+		}
+		n.Filtering = AddressDependent
+		return nil
+	}
+	n.Filtering = AddressPortDependent
+	return nil
+}
+
+// changed sends a Binding request with the CHANGE-REQUEST flags and returns the
+// address the response was sent from, or an invalid address when there is no response.
+func (c Client) changed(ctx context.Context, conn net.PacketConn, server net.Addr, flags uint32,
+) (netip.AddrPort, error) {
+	wait, cancel := context.WithTimeout(ctx, c.wait())
+	defer cancel()
+	_, from, err := c.Binding(wait, conn, server, ChangeRequest(flags))
+	switch {
+	case err == nil:
+		ap, err := netip.ParseAddrPort(from.String())
+		if err != nil {
+			return netip.AddrPort{}, fmt.Errorf("%w: response from %s", ErrChange, from)
+		}
+		return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+	case ctx.Err() != nil:
+		return netip.AddrPort{}, ctx.Err()
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrNoResponse):
+		return netip.AddrPort{}, nil
+	}
+	return netip.AddrPort{}, err
+}
+
+// wait is the duration of a filtering test, which covers three transmissions.
+func (c Client) wait() time.Duration {
+	const rtos = 6
+	return rtos * c.rto()
+}
+
+// local returns the transport address of the connection that is used to reach the server,
+// as the connection listens on the unspecified address.
+func local(conn *net.UDPConn, server *net.UDPAddr) (netip.AddrPort, error) {
+	// a connected udp socket sends nothing, but selects the outgoing interface
+	route, err := net.DialUDP(conn.LocalAddr().Network(), nil, server)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	defer route.Close()
+	ip, err := netip.ParseAddrPort(route.LocalAddr().String())
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := netip.ParseAddrPort(conn.LocalAddr().String())
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(ip.Addr().Unmap(), port.Port()), nil
+}
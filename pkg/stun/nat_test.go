@@ -0,0 +1,74 @@
+package stun_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/stun"
+)
+
+func TestClient_Detect(t *testing.T) {
+	const (
+		ei  = stun.EndpointIndependent
+		ad  = stun.AddressDependent
+		apd = stun.AddressPortDependent
+	)
+	tests := []struct {
+		mapping   stun.Behavior
+		filtering stun.Behavior
+		want      string
+	}{
+		{ei, ei, "full cone"},
+		{ei, ad, "restricted cone"},
+		{ei, apd, "port restricted cone"},
+		{ad, apd, "symmetric"},
+		{apd, apd, "symmetric"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mapping.String()+" "+tt.filtering.String(), func(t *testing.T) {
+			srv := myiptest.NATServer(t, tt.mapping, tt.filtering)
+			c := stun.Client{RTO: 10 * time.Millisecond}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			n, err := c.Detect(ctx, srv.Link, false)
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			if n.Mapping != tt.mapping || n.Filtering != tt.filtering {
+				t.Errorf("Detect() = %s mapping and %s filtering, want %s and %s",
+					n.Mapping, n.Filtering, tt.mapping, tt.filtering)
+			}
+			if got := n.Type(); got != tt.want {
+				t.Errorf("Detect() Type() = %q, want %q", got, tt.want)
+			}
+			if !n.Translated() || n.Mapped.Addr().String() != myiptest.IPv4 || n.Other.Addr().String() != "127.0.0.2" {
+				t.Errorf("Detect() = %+v, want the emulated nat addresses", n)
+			}
+		})
+	}
+}
+
+func TestClient_Detect_rfc3489(t *testing.T) {
+	srv := myiptest.NewSTUNServer(myiptest.OK)
+	defer srv.Close()
+	c := stun.Client{RTO: 10 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Detect(ctx, srv.Link, false); !errors.Is(err, stun.ErrRFC5780) {
+		t.Errorf("Detect() error = %v, want %v", err, stun.ErrRFC5780)
+	}
+}
+
+func TestNAT_Type(t *testing.T) {
+	n := stun.NAT{Filtering: stun.AddressPortDependent}
+	if got := n.Type(); got != "firewall" {
+		t.Errorf("Type() = %q, want firewall when the address is not translated", got)
+	}
+	n.Filtering = stun.EndpointIndependent
+	if got := n.Type(); got != "open internet" {
+		t.Errorf("Type() = %q, want open internet", got)
+	}
+}
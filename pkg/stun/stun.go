@@ -0,0 +1,259 @@
+// Package stun returns your Internet-facing IPv4 or IPv6 address,
+// sourced from the XOR-MAPPED-ADDRESS of a STUN (RFC 5389) Binding
+// response over UDP, for use on networks where outbound HTTPS is proxied.
+// © Ben Garrett https://github.com/bengarrett/myip
+package stun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/fault"
+)
+
+var (
+	ErrNoIPv4     = errors.New("ip address is not ipv4")
+	ErrNoIPv6     = errors.New("ip address is not ipv6")
+	ErrLink       = errors.New("stun link is invalid, it must use the stun:host:port form")
+	ErrNoResponse = errors.New("stun server did not respond")
+	ErrResponse   = errors.New("stun server error response")
+)
+
+const (
+	domain = "stun"
+	port   = "3478"
+
+	// RTO is the default initial retransmission timeout.
+	RTO = 500 * time.Millisecond
+	// Rc is the maximum number of request transmissions.
+	Rc = 7
+	// Rm is the multiple of the RTO to wait for a response after the last transmission.
+	Rm = 16
+)
+
+// The default STUN servers.
+const (
+	Google     = "stun:stun.l.google.com:19302"  // Google is the Google STUN server.
+	Cloudflare = "stun:stun.cloudflare.com:3478" // Cloudflare is the Cloudflare STUN server.
+)
+
+// Client requests the STUN servers over UDP.
+// The zero value uses the Google and Cloudflare servers.
+type Client struct {
+	Servers []string      // Servers are the STUN links to request, an empty value uses Google and Cloudflare.
+	RTO     time.Duration // RTO is the initial retransmission timeout, a zero value uses RTO.
+}
+
+func (c Client) rto() time.Duration {
+	if c.RTO <= 0 {
+		return RTO
+	}
+	return c.RTO
+}
+
+// Links returns the STUN links used by the client.
+func (c Client) Links() []string {
+	if len(c.Servers) == 0 {
+		return []string{Google, Cloudflare}
+	}
+	return c.Servers
+}
+
+// URL returns the STUN links used by the client separated by spaces.
+func (c Client) URL() string {
+	return strings.Join(c.Links(), " ")
+}
+
+// IPv4 returns the clients online IP address.
+func (c Client) IPv4(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	ap, err := c.Request(ctx, cancel, false)
+	if err != nil {
+		return "", err
+	}
+	return ap.Addr().String(), nil
+}
+
+// IPv6 returns the clients online IP address. Using this on a network
+// that does not support IPv6 will result in an error.
+func (c Client) IPv6(ctx context.Context, cancel context.CancelFunc) (string, error) {
+	ap, err := c.Request(ctx, cancel, true)
+	if err != nil {
+		return "", err
+	}
+	return ap.Addr().String(), nil
+}
+
+// Request concurrently sends a Binding request to every server over the address family
+// and returns the first reflexive transport address to be received.
+func (c Client) Request(ctx context.Context, cancel context.CancelFunc, ipv6 bool) (netip.AddrPort, error) {
+	defer cancel()
+
+	type reply struct {
+		ap  netip.AddrPort
+		err error
+	}
+	links := c.Links()
+	replies := make(chan reply, len(links))
+	for _, link := range links {
+		go func(link string) {
+			ap, err := c.mapped(ctx, link, ipv6)
+			replies <- reply{ap, err}
+		}(link)
+	}
+	var err error
+	for range links {
+		r := <-replies
+		if r.err == nil {
+			return r.ap, nil
+		}
+		if err == nil {
+			err = r.err
+		}
+	}
+	return netip.AddrPort{}, err
+}
+
+func (c Client) mapped(ctx context.Context, link string, ipv6 bool) (netip.AddrPort, error) {
+	network := "4"
+	if ipv6 {
+		network = "6"
+	}
+	server, err := Parse(link)
+	if err != nil {
+		return netip.AddrPort{}, fault.Wrap(domain, fault.Request, err)
+	}
+	host, _, _ := net.SplitHostPort(server)
+	raddr, err := resolve(ctx, network, server)
+	if err != nil {
+		return netip.AddrPort{}, fault.New(host, err)
+	}
+	conn, err := net.ListenUDP("udp"+network, nil)
+	if err != nil {
+		return netip.AddrPort{}, fault.New(host, err)
+	}
+	defer conn.Close()
+	res, _, err := c.Binding(ctx, conn, raddr)
+	if err != nil {
+		return netip.AddrPort{}, fault.New(host, err)
+	}
+	ap, err := res.Mapped()
+	if err != nil {
+		return netip.AddrPort{}, fault.Wrap(host, fault.Parse, err)
+	}
+	if err := Valid(ipv6, ap); err != nil {
+		return ap, fault.Wrap(host, fault.Validation, err)
+	}
+	return ap, nil
+}
+
+// resolve returns the UDP address of the server host and port using the IP network, either 4 or 6.
+func resolve(ctx context.Context, network, server string) (*net.UDPAddr, error) {
+	host, p, _ := net.SplitHostPort(server)
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", p)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip"+network, host)
+	if err != nil {
+		return nil, err
+	}
+	return net.UDPAddrFromAddrPort(netip.AddrPortFrom(ips[0].Unmap(), uint16(port))), nil
+}
+
+// Binding sends a Binding request containing the attributes from the connection to the
+// server and returns the response and the address it was sent from. The request is
+// retransmitted using the RFC 5389 timers, starting with the RTO and doubling it after
+// each of the Rc transmissions, until a response to the transaction is received.
+func (c Client) Binding(ctx context.Context, conn net.PacketConn, server net.Addr, attrs ...Attribute,
+) (Message, net.Addr, error) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stop()
+	req, err := NewRequest(attrs...)
+	if err != nil {
+		return Message{}, nil, err
+	}
+	b := req.Pack()
+	buf := make([]byte, 1500)
+	rto := c.rto()
+	for i := 0; i < Rc; i++ {
+		if err := ctx.Err(); err != nil {
+			return Message{}, nil, err
+		}
+		if _, err := conn.WriteTo(b, server); err != nil {
+			return Message{}, nil, err
+		}
+		wait := rto
+		if i == Rc-1 {
+			wait = Rm * c.rto()
+		}
+		deadline := time.Now().Add(wait)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return Message{}, nil, err
+		}
+		res, from, err := read(conn, req.ID, buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			rto *= 2
+			continue
+		}
+		if err != nil {
+			return Message{}, nil, err
+		}
+		if res.Type == BindingError {
+			code, reason := res.Error()
+			return res, from, fmt.Errorf("%w: %d %s", ErrResponse, code, reason)
+		}
+		return res, from, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return Message{}, nil, err
+	}
+	return Message{}, nil, fmt.Errorf("%w: %w", ErrNoResponse, os.ErrDeadlineExceeded)
+}
+
+// read returns the first response to the transaction, any other datagrams are ignored.
+func read(conn net.PacketConn, id [12]byte, buf []byte) (Message, net.Addr, error) {
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return Message{}, nil, err
+		}
+		m, err := Unpack(buf[:n])
+		if err != nil || m.ID != id || (m.Type != BindingSuccess && m.Type != BindingError) {
+			continue
+		}
+		return m, from, nil
+	}
+}
+
+// Parse returns the host and port of a STUN link, the port defaults to 3478.
+func Parse(link string) (string, error) {
+	s, ok := strings.CutPrefix(link, "stun:")
+	if !ok || s == "" || strings.Contains(s, "/") {
+		return "", fmt.Errorf("%q: %w", link, ErrLink)
+	}
+	if _, _, err := net.SplitHostPort(s); err == nil {
+		return s, nil
+	}
+	return net.JoinHostPort(strings.Trim(s, "[]"), port), nil
+}
+
+// Valid returns nil if the address is of the family.
+func Valid(ipv6 bool, ap netip.AddrPort) error {
+	ip := ap.Addr().Unmap()
+	switch {
+	case ipv6 && !ip.Is6():
+		return ErrNoIPv6
+	case !ipv6 && !ip.Is4():
+		return ErrNoIPv4
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package stun_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/fault"
+	"github.com/bengarrett/myip/pkg/myiptest"
+	"github.com/bengarrett/myip/pkg/stun"
+)
+
+// ExampleClient_IPv4 demonstrates a STUN request with a 5 second timeout.
+func ExampleClient_IPv4() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := stun.Client{}.IPv4(ctx, cancel)
+	if err != nil {
+		log.Printf("\n%s\n", err)
+	}
+	fmt.Println(s)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+		err  error
+	}{
+		{stun.Google, "stun.l.google.com:19302", nil},
+		{"stun:stun.example.com", "stun.example.com:3478", nil},
+		{"stun:[2001:db8::1]", "[2001:db8::1]:3478", nil},
+		{"stun:[2001:db8::1]:3479", "[2001:db8::1]:3479", nil},
+		{"stun.example.com", "", stun.ErrLink},
+		{"stun://stun.example.com", "", stun.ErrLink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			got, err := stun.Parse(tt.link)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("Parse() = %q, %v, want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior myiptest.Behavior
+		drop     int
+		want     string
+		phase    fault.Phase
+	}{
+		{"ok", myiptest.OK, 0, myiptest.IPv4, 0},
+		{"retransmit", myiptest.OK, 2, myiptest.IPv4, 0},
+		{"wrong family", myiptest.WrongFamily, 0, "", fault.Validation},
+		{"error", myiptest.Status, 0, "", fault.Request},
+		{"malformed", myiptest.Malformed, 0, "", fault.Timeout},
+		{"slow", myiptest.Slow, 0, "", fault.Timeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := myiptest.NewSTUNServer(tt.behavior)
+			defer srv.Close()
+			srv.Drop(tt.drop)
+			c := stun.Client{Servers: []string{srv.Link}, RTO: 10 * time.Millisecond}
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			s, err := c.IPv4(ctx, cancel)
+			if tt.want != "" {
+				if err != nil || s != tt.want {
+					t.Errorf("IPv4() = %q, %v, want %q", s, err, tt.want)
+				}
+				return
+			}
+			if !fault.Is(err, tt.phase) {
+				t.Errorf("IPv4() error = %v, want the %s phase", err, tt.phase)
+			}
+		})
+	}
+}
+
+func TestClient_Request(t *testing.T) {
+	slow := myiptest.NewSTUNServer(myiptest.Slow)
+	defer slow.Close()
+	ok := myiptest.NewSTUNServer(myiptest.OK)
+	defer ok.Close()
+	c := stun.Client{Servers: []string{slow.Link, ok.Link}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ap, err := c.Request(ctx, cancel, false)
+	if err != nil || ap.Addr().String() != myiptest.IPv4 || ap.Port() == 0 {
+		t.Errorf("Request() = %v, %v, want the first reply of %v", ap, err, myiptest.IPv4)
+	}
+}
+
+func TestClient_Binding(t *testing.T) {
+	srv := myiptest.NewSTUNServer(myiptest.Slow)
+	defer srv.Close()
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	raddr, err := net.ResolveUDPAddr("udp4", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7 transmissions with a 1ms rto take 1+2+4+8+16+32 ms and a final 16ms wait
+	c := stun.Client{RTO: time.Millisecond}
+	start := time.Now()
+	_, _, err = c.Binding(context.Background(), conn, raddr)
+	if !errors.Is(err, stun.ErrNoResponse) {
+		t.Errorf("Binding() error = %v, want %v", err, stun.ErrNoResponse)
+	}
+	if d := time.Since(start); d < 79*time.Millisecond {
+		t.Errorf("Binding() returned after %v, want the retransmission timers", d)
+	}
+}
@@ -0,0 +1,50 @@
+// Package tmplfunc provides the helper functions shared by the
+// output templates and the webhook body templates.
+// © Ben Garrett https://github.com/bengarrett/myip
+package tmplfunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Map returns the helper functions for use with template.Funcs.
+//
+//   - ms returns a duration in milliseconds.
+//   - join concatenates a list using the separator, {{join ", " .Providers}} or {{.Providers | join ", "}}.
+//   - lower and upper change the case of a string.
+//   - default returns the first argument when the string is empty, {{.City | default "unknown"}}.
+//   - json encodes a value as JSON, {{json .New}}.
+func Map() template.FuncMap {
+	return template.FuncMap{
+		"ms":    func(d time.Duration) int64 { return d.Milliseconds() },
+		"join":  Join,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"default": func(def, s string) string {
+			if s == "" {
+				return def
+			}
+			return s
+		},
+		"json": JSON,
+	}
+}
+
+// Join concatenates the elements of a using the separator.
+// The separator is the first argument so the list can be piped to the function.
+func Join(sep string, a []string) string {
+	return strings.Join(a, sep)
+}
+
+// JSON returns the value encoded as JSON without the HTML escapes.
+func JSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n"), err
+}
@@ -0,0 +1,95 @@
+// Package watch polls the providers on a schedule and
+// reports changes to the Internet-facing IP address and location.
+// © Ben Garrett https://github.com/bengarrett/myip
+package watch
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/geolite2"
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+)
+
+var ErrInterval = errors.New("watch interval must be greater than zero")
+
+// Observation is the IP address of an address family that most providers agree on.
+type Observation struct {
+	Time      time.Time         // Time is when the providers were polled.
+	Family    provider.Family   // Family is the address family of the IP.
+	IP        string            // IP is the address most providers agree on.
+	Location  geolite2.Location // Location is the geographic location of the IP.
+	Providers []string          // Providers are the names of the providers that returned the IP.
+	Total     int               // Total is the number of providers requested, including those that failed.
+}
+
+// Observe returns the observation of the address family from the results.
+// The IP is empty when no provider returns an address of the family.
+func Observe(results []provider.Result, f provider.Family) Observation {
+	c := resolver.Vote(resolver.Filter(results, f))
+	o := Observation{
+		Time:      time.Now(),
+		Family:    f,
+		IP:        c.IP,
+		Providers: []string{},
+		Total:     c.Total(),
+	}
+	for _, r := range c.Agree {
+		o.Providers = append(o.Providers, r.Provider)
+	}
+	if len(c.Agree) > 0 {
+		o.Location = c.Agree[0].Location
+	}
+	return o
+}
+
+// Changed reports whether the IP address or location of next differs from o.
+func (o Observation) Changed(next Observation) bool {
+	return o.IP != next.IP || o.Location != next.Location
+}
+
+// Watcher polls the resolver every interval.
+type Watcher struct {
+	Resolver resolver.Resolver // Resolver used to request the providers.
+	Interval time.Duration     // Interval is the duration between each poll.
+	Tick     func(Observation) // Tick is called with every observation that has an address, a nil value is ignored.
+}
+
+// Run polls the providers immediately and then every interval until the context is done.
+// The change function is called with the previous and next observations of each address family,
+// both on the first successful poll and on any later change to the address or location.
+// Polls where no provider returns an address are ignored.
+func (w Watcher) Run(ctx context.Context, change func(prev, next Observation)) error {
+	if w.Interval <= 0 {
+		return ErrInterval
+	}
+	prev := map[provider.Family]Observation{}
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		results := w.Resolver.Results(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		for _, f := range w.Resolver.Family.Families() {
+			next := Observe(results, f)
+			if next.IP == "" {
+				continue
+			}
+			if w.Tick != nil {
+				w.Tick(next)
+			}
+			if p := prev[f]; p.Changed(next) {
+				change(p, next)
+			}
+			prev[f] = next
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bengarrett/myip/pkg/provider"
+	"github.com/bengarrett/myip/pkg/resolver"
+	"github.com/bengarrett/myip/pkg/watch"
+)
+
+// addresses returns a provider that replies with each address in turn,
+// repeating the final address.
+func addresses(ips ...string) provider.Service {
+	var i atomic.Int32
+	req := func(_ context.Context, cancel context.CancelFunc) (string, error) {
+		defer cancel()
+		n := int(i.Add(1)) - 1
+		if n >= len(ips) {
+			n = len(ips) - 1
+		}
+		return ips[n], nil
+	}
+	return provider.Service{ID: "fake", Linkv4: "http://localhost", IPv4: req}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	r := resolver.Resolver{
+		Family:   provider.IPv4,
+		Timeout:  time.Second,
+		Registry: provider.NewRegistry(addresses("0.0.0.0", "0.0.0.0", "", "0.0.0.0", "0.0.0.1")),
+	}
+	ticks := 0
+	w := watch.Watcher{Resolver: r, Interval: time.Millisecond, Tick: func(watch.Observation) { ticks++ }}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := []string{}
+	err := w.Run(ctx, func(prev, next watch.Observation) {
+		changes = append(changes, prev.IP+">"+next.IP)
+		if len(changes) == 2 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+	want := []string{">0.0.0.0", "0.0.0.0>0.0.0.1"}
+	if len(changes) != len(want) {
+		t.Fatalf("Run() changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Run() change %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+	if ticks != 4 {
+		t.Errorf("Run() ticks = %d, want 4", ticks)
+	}
+	w.Interval = 0
+	if err := w.Run(ctx, nil); !errors.Is(err, watch.ErrInterval) {
+		t.Errorf("Run() error = %v, want %v", err, watch.ErrInterval)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	results := []provider.Result{
+		{Provider: "a", Family: provider.IPv4, IP: "192.0.2.1"},
+		{Provider: "b", Family: provider.IPv4, IP: "192.0.2.1"},
+		{Provider: "c", Family: provider.IPv4, IP: "192.0.2.2"},
+		{Provider: "a", Family: provider.IPv6, IP: "2001:db8::1"},
+	}
+	o := watch.Observe(results, provider.IPv4)
+	if o.IP != "192.0.2.1" || len(o.Providers) != 2 || o.Total != 3 {
+		t.Errorf("Observe() = %v %v of %d, want 192.0.2.1 [a b] of 3", o.IP, o.Providers, o.Total)
+	}
+	if !o.Changed(watch.Observe(results, provider.IPv6)) {
+		t.Errorf("Changed() = false, want true")
+	}
+	if o.Changed(watch.Observe(results, provider.IPv4)) {
+		t.Errorf("Changed() = true, want false")
+	}
+}